@@ -17,7 +17,7 @@ in {
 
     src = inputs.self + /src;
 
-    vendorHash = "sha256-ja0nFWdWqieq8m6cSKAhE1ibeN0fODDCC837jw0eCnE=";
+    vendorHash = "sha256-bxMY080QHHoz1VZWih+K6pj6HSqROK3ACTYec0+Hf8U=";
 
     nativeBuildInputs = [nixpkgs.installShellFiles];
 
@@ -129,6 +129,15 @@ type Entry struct {
 // Note that finding an output ID does not guarantee that the
 // saved file for that output ID is still available.
 func (c *Cache) Get(id ActionID) (Entry, error) {
+	entry, err := c.get(id)
+	if err == nil {
+		c.used(c.fileName(id, "a"))
+	}
+	return entry, err
+}
+
+// get is Get without marking the entry as used.
+func (c *Cache) get(id ActionID) (Entry, error) {
 	missing := func(reason error) (Entry, error) {
 		return Entry{}, &entryNotFoundError{Err: reason}
 	}
@@ -185,8 +194,6 @@ func (c *Cache) Get(id ActionID) (Entry, error) {
 		return missing(errors.New("negative timestamp"))
 	}
 
-	c.used(c.fileName(id, "a"))
-
 	return Entry{buf, size, time.Unix(0, tm)}, nil
 }
 
@@ -278,9 +285,6 @@ func (c *Cache) TrimWithLimit(trimInterval, trimLimit time.Duration) {
 	if trimInterval <= 0 {
 		trimInterval = DefaultTrimInterval
 	}
-	if trimLimit <= 0 {
-		trimLimit = DefaultTrimLimit
-	}
 	now := c.now()
 
 	// We maintain in dir/trim.txt the time of the last completed cache trim.
@@ -299,26 +303,53 @@ func (c *Cache) TrimWithLimit(trimInterval, trimLimit time.Duration) {
 		}
 	}
 
+	c.TrimNow(trimLimit)
+}
+
+// TrimNow removes the entries that weren't used for trimLimit, regardless
+// of when the cache was trimmed last, and returns the number of removed
+// entries and the size of the removed files.
+//
+// trimLimit <= 0 means Default.
+func (c *Cache) TrimNow(trimLimit time.Duration) (int, int64) {
+	if trimLimit <= 0 {
+		trimLimit = DefaultTrimLimit
+	}
+	now := c.now()
+
 	// Trim each of the 256 subdirectories.
 	// We subtract an additional mtimeInterval
 	// to account for the imprecision of our "last used" mtimes.
 	cutoff := now.Add(-trimLimit - c.mtimeInterval)
-	for i := 0; i < 256; i++ {
-		subdir := filepath.Join(c.dir, fmt.Sprintf("%02x", i))
-		c.trimSubdir(subdir, cutoff)
-	}
+	entries, size := c.trimAll(cutoff)
 
 	// Ignore errors from here: if we don't write the complete timestamp, the
 	// cache will appear older than it is, and we'll trim it again next time.
 	var b bytes.Buffer
 	fmt.Fprintf(&b, "%d", now.Unix())
-	if err := lockedfile.Write(filepath.Join(c.dir, "trim.txt"), &b, 0666); err != nil {
-		return
+	_ = lockedfile.Write(filepath.Join(c.dir, "trim.txt"), &b, 0666)
+	return entries, size
+}
+
+// Clear removes all entries and returns their number and the size of the
+// removed files.
+func (c *Cache) Clear() (int, int64) {
+	return c.trimAll(c.now().Add(c.mtimeInterval + time.Hour))
+}
+
+func (c *Cache) trimAll(cutoff time.Time) (entries int, size int64) {
+	for i := 0; i < 256; i++ {
+		subdir := filepath.Join(c.dir, fmt.Sprintf("%02x", i))
+		n, s := c.trimSubdir(subdir, cutoff)
+		entries += n
+		size += s
 	}
+	return entries, size
 }
 
-// trimSubdir trims a single cache subdirectory.
-func (c *Cache) trimSubdir(subdir string, cutoff time.Time) {
+// trimSubdir trims a single cache subdirectory and returns the number of
+// removed entries and the size of the removed files.
+func (c *Cache) trimSubdir(subdir string, cutoff time.Time) (entries int, size int64) {
 	// Read all directory entries from subdir before removing
 	// any files, in case removing files invalidates the file offset
 	// in the directory scan. Also, ignore error from f.Readdirnames,
@@ -338,10 +369,14 @@ func (c *Cache) trimSubdir(subdir string, cutoff time.Time) {
 		}
 		entry := filepath.Join(subdir, name)
 		info, err := os.Stat(entry)
-		if err == nil && info.ModTime().Before(cutoff) {
-			os.Remove(entry)
+		if err == nil && info.ModTime().Before(cutoff) && os.Remove(entry) == nil {
+			size += info.Size()
+			if strings.HasSuffix(name, "-a") {
+				entries++
+			}
 		}
 	}
+	return entries, size
 }
 
 // putIndexEntry adds an entry to the cache recording that executing the action
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is an action entry of the cache.
+type Record struct {
+	ID ActionID
+	Entry
+	// Used is when the entry was used last, give or take the mtime interval.
+	Used time.Time
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string { return c.dir }
+
+// Records returns the action entries of the cache, most recently used first,
+// without marking them as used. Invalid entries are skipped.
+func (c *Cache) Records() ([]Record, error) {
+	var records []Record
+	for i := 0; i < 256; i++ {
+		names, err := filepath.Glob(filepath.Join(c.dir, fmt.Sprintf("%02x", i), "*-a"))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			var id ActionID
+			if _, err := hex.Decode(id[:], []byte(strings.TrimSuffix(filepath.Base(name), "-a"))); err != nil {
+				continue
+			}
+			entry, err := c.get(id)
+			if err != nil {
+				continue
+			}
+			info, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			records = append(records, Record{id, entry, info.ModTime()})
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Used.After(records[j].Used) })
+	return records, nil
+}
+
+// Find returns the record whose hex action ID starts with the prefix.
+func (c *Cache) Find(prefix string) (Record, error) {
+	records, err := c.Records()
+	if err != nil {
+		return Record{}, err
+	}
+	var found []Record
+	for _, r := range records {
+		if strings.HasPrefix(fmt.Sprintf("%x", r.ID), strings.ToLower(prefix)) {
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Record{}, fmt.Errorf("no cache entry '%s'", prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Record{}, fmt.Errorf("cache entry '%s' is ambiguous, it matches %d entries", prefix, len(found))
+}
+
+// DiskUsage returns the size of all entries' files.
+func (c *Cache) DiskUsage() (int64, error) {
+	var size int64
+	for i := 0; i < 256; i++ {
+		names, err := filepath.Glob(filepath.Join(c.dir, fmt.Sprintf("%02x", i), "*-[ad]"))
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range names {
+			if info, err := os.Stat(name); err == nil {
+				size += info.Size()
+			}
+		}
+	}
+	return size, nil
+}
+
+// FormatSize formats a size in bytes with a binary unit, e.g. '1.5 KiB'.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecords(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const start = 1000000000
+	now := int64(start)
+	c.now = func() time.Time { return time.Unix(now, 0) }
+
+	old, recent := ActionID(dummyID(1)), ActionID(dummyID(2))
+	_ = c.PutBytes(old, []byte("abc"))
+	now = start + 10*24*3600
+	_ = c.PutBytes(recent, []byte("defg"))
+
+	records, err := c.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID != recent || records[1].ID != old || records[0].Size != 4 {
+		t.Fatalf("got %+v, want the recent and then the old entry", records)
+	}
+	if r, err := c.Find(fmt.Sprintf("%x", recent)[:8]); err != nil || r.ID != recent {
+		t.Errorf("Find: got %x, %v", r.ID, err)
+	}
+	if _, err := c.Find(fmt.Sprintf("%x", recent)[:1]); err == nil {
+		t.Error("Find: got no error for an ambiguous prefix")
+	}
+
+	if entries, size := c.TrimNow(24 * time.Hour); entries != 1 || size == 0 {
+		t.Errorf("TrimNow: removed %d entries of %d bytes, want 1", entries, size)
+	}
+	if _, err := c.Get(old); err == nil {
+		t.Error("the old entry wasn't trimmed")
+	}
+	if entries, _ := c.Clear(); entries != 1 {
+		t.Errorf("Clear: removed %d entries, want 1", entries)
+	}
+	if size, err := c.DiskUsage(); err != nil || size != 0 {
+		t.Errorf("DiskUsage: got %d, %v after Clear", size, err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		512:              "512 B",
+		1536:             "1.5 KiB",
+		50 * 1024 * 1024: "50.0 MiB",
+	} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("%d: got %s, want %s", n, got, want)
+		}
+	}
+}
@@ -1,20 +1,34 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"text/tabwriter"
-
-	"github.com/oriser/regroup"
+	"time"
 
 	"github.com/rsteube/carapace"
 	"github.com/rsteube/carapace/pkg/style"
 	"github.com/spf13/cobra"
 
+	"github.com/paisano-nix/paisano/cache"
+	"github.com/paisano-nix/paisano/config"
 	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/filter"
 	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/graph"
+	"github.com/paisano-nix/paisano/history"
+	"github.com/paisano-nix/paisano/lint"
+	"github.com/paisano-nix/paisano/styles"
+	"github.com/paisano-nix/paisano/watch"
 )
 
 type Spec struct {
@@ -24,9 +38,39 @@ type Spec struct {
 	Action string `regroup:"action,required"`
 }
 
-var re = regroup.MustCompile(`^//(?P<cell>[^/]+)/(?P<block>[^/]+)/(?P<target>.+):(?P<action>[^:]+)`)
+var re = data.SpecRe
+
+var (
+	forSystem     string
+	listOutput    string
+	listBlockType string
+	listAction    string
+	listHasReadme bool
+	listQuery     string
+
+	runWithDeps  bool
+	runKeepGoing bool
 
-var forSystem string
+	runManyParallel int
+	runManyFailFast bool
+
+	depsFormat string
+
+	historyJson    bool
+	historyLimit   int
+	historyFailed  bool
+	checkSchema    bool
+	checkOutput    string
+	checkProfile   bool
+	checkEvalStats bool
+	checkSeverity  map[string]string
+	watchDebounce  time.Duration
+	cacheOlder     time.Duration
+	cacheShowRaw   bool
+	tuiTheme       string
+	tuiWatch       bool
+	tuiStayOpen    bool
+)
 
 var rootCmd = &cobra.Command{
 	Use:                   fmt.Sprintf("%[1]s //[cell]/[block]/[target]:[action] [args...]", argv0),
@@ -35,37 +79,296 @@ var rootCmd = &cobra.Command{
 	Short:                 fmt.Sprintf("%[1]s is the CLI / TUI companion for %[2]s", argv0, project),
 	Long: fmt.Sprintf(`%[1]s is the CLI / TUI companion for %[2]s.
 
-- Invoke without any arguments to start the TUI.
+- Invoke without any arguments (or only with '--theme', '--watch' or '--stay-open') to start the TUI.
 - Invoke with a target spec and action to run a known target's action directly.
 
 Enable autocompletion via '%[1]s _carapace <shell>'.
 For more instructions, see: https://rsteube.github.io/carapace/carapace/gen/hiddenSubcommand.html
 `, argv0, project),
+	PersistentPreRunE: loadConfig,
 	Args: func(cmd *cobra.Command, args []string) error {
-		s := &Spec{}
-		if err := re.MatchToTarget(args[0], s); err != nil {
-			return fmt.Errorf("invalid argument format: %s", args[0])
+		if len(args) == 0 {
+			return nil
 		}
-		return nil
+		return runArgs(cmd, args)
 	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			runTui()
+			return nil
+		}
+		return runE(cmd, args)
+	},
+}
+
+// loadConfig loads the config, whose settings are the defaults of the flags
+// that weren't set.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		// a broken config isn't a usage error, and ExecuteCli reports it
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return err
+	}
+	flags := cmd.Flags()
+	if !flags.Changed("for") {
+		forSystem = cfg.System
+	}
+	if !flags.Changed("theme") {
+		tuiTheme = cfg.Theme
+	}
+	if !flags.Changed("stay-open") {
+		tuiStayOpen = cfg.StayOpen
+	}
+	return nil
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run //[cell]/[block]/[target]:[action] [args...]",
+	Short: "Run a target's action.",
+	Long: `Run a target's action, same as invoking with the target spec directly.
+With '--with-deps', the same-named action of every dependency of the target is run first,
+in dependency order. Dependencies without such an action are skipped.`,
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	SilenceErrors:         true,
+	Args:                  runArgs,
+	RunE:                  runE,
+}
+
+func runArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("requires a target spec")
+	}
+	s := &Spec{}
+	if err := re.MatchToTarget(args[0], s); err != nil {
+		return fmt.Errorf("invalid argument format: %s", args[0])
+	}
+	return nil
+}
+
+func runE(cmd *cobra.Command, args []string) error {
+	s := &Spec{}
+	if err := re.MatchToTarget(args[0], s); err != nil {
+		return err
+	}
+	command := &flake.RunActionCmd{
+		System: forSystem,
+		Cell:   s.Cell,
+		Block:  s.Block,
+		Target: s.Target,
+		Action: s.Action}
+	var (
+		code int
+		err  error
+	)
+	if runWithDeps {
+		code, err = runActionWithDeps(command, args[1:])
+	} else {
+		code, err = runAction(command, args[1:], history.SourceCli)
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(code)
+	return nil
+}
+
+// runAction runs the action as a child process, records it in the history
+// and returns the action's exit code.
+func runAction(command *flake.RunActionCmd, args []string, source string) (int, error) {
+	return runActionContext(context.Background(), command, args, source)
+}
+
+// runActionContext is runAction, interrupting the action once the context
+// is done.
+func runActionContext(ctx context.Context, command *flake.RunActionCmd, args []string, source string) (int, error) {
+	res, err := command.Run(ctx, args)
+	if err != nil {
+		return 1, err
+	}
+	if err := history.Append(history.NewEntry(command, args, res, source)); err != nil {
+		fmt.Fprintf(os.Stderr, "while recording history: %v\n", err)
+	}
+	return res.ExitCode, nil
+}
+
+// runActionWithDeps runs the same-named action of all dependencies of the
+// command's target in dependency order before running the command itself.
+// Extra arguments are only passed to the command. Once an action fails, no
+// further actions are run unless --keep-going is set, in which case only the
+// actions depending on a failed one are skipped.
+func runActionWithDeps(command *flake.RunActionCmd, args []string) (int, error) {
+	root, err := LoadRoot()
+	if err != nil {
+		return 1, err
+	}
+	g := graph.New(root)
+	id := fmt.Sprintf("//%s/%s/%s", command.Cell, command.Block, command.Target)
+	if !g.Has(id) {
+		return 1, fmt.Errorf("unknown target: %s", id)
+	}
+	order, err := g.Order(id)
+	if err != nil {
+		return 1, err
+	}
+	hasAction := map[string]bool{}
+	for _, rec := range root.ActionRecords() {
+		hasAction[rec.Spec] = true
+	}
+	var (
+		results []flake.BatchResult
+		failed  = map[string]bool{}
+		stop    bool
+		code    = 1
+	)
+	for _, n := range order {
+		var (
+			cmd     = command
+			cmdArgs []string
+		)
+		if n == id {
+			cmdArgs = args
+		} else {
+			if !hasAction[n+":"+command.Action] {
+				fmt.Fprintf(os.Stderr, "%s has no action '%s', skipping it\n", n, command.Action)
+				continue
+			}
+			parts := strings.SplitN(strings.TrimPrefix(n, "//"), "/", 3)
+			cmd = &flake.RunActionCmd{
+				System: command.System,
+				Cell:   parts[0],
+				Block:  parts[1],
+				Target: parts[2],
+				Action: command.Action,
+			}
+		}
+		blocked := stop
+		for _, d := range g.Closure(n, false)[1:] {
+			blocked = blocked || failed[d]
+		}
+		if blocked {
+			failed[n] = true
+			results = append(results, flake.BatchResult{Cmd: *cmd, Skipped: true})
+			continue
+		}
+		start := time.Now()
+		exitCode, err := runAction(cmd, cmdArgs, history.SourceCli)
+		results = append(results, flake.BatchResult{Cmd: *cmd, ExitCode: exitCode, Duration: time.Since(start), Err: err})
+		if err != nil || exitCode != 0 {
+			failed[n] = true
+			stop = !runKeepGoing
+		}
+		if n == id && err == nil {
+			code = exitCode
+		}
+	}
+	if len(results) > 1 {
+		if err := printBatchSummary(os.Stderr, results); err != nil && code == 0 {
+			code = 1
+		}
+	}
+	return code, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration.",
+	Long: fmt.Sprintf(`Inspect the configuration.
+%[1]s reads the project's '%[2]s' and the user's config file, which takes precedence.`, argv0, config.ProjectFile),
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration.",
+	Long: `Show the effective configuration, and for every setting where it was set.
+Settings that are not set in any config file keep their default.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		fmt.Println("# config files, later ones take precedence:")
+		for _, path := range config.Paths() {
+			fmt.Printf("#   %s\n", path)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 5, 2, 2, ' ', 0)
+		for _, s := range cfg.Settings() {
+			fmt.Fprintf(w, "%s = %s\t# %s\n", s, cfg.Value(s), cfg.Sources[s])
+		}
+		return w.Flush()
+	},
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch //[cell]/[block]/[target]:[action] [args...]",
+	Short: "Re-run a target's action whenever the flake's sources change.",
+	Long: `Run a target's action and re-run it whenever the flake's sources change,
+i.e. 'flake.nix', 'flake.lock' or any file in the cells directory. A run still going on is
+interrupted first. Stop watching with ctrl+c.`,
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	SilenceErrors:         true,
+	Args:                  runArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s := &Spec{}
 		if err := re.MatchToTarget(args[0], s); err != nil {
 			return err
 		}
-		command := flake.RunActionCmd{
+		command := &flake.RunActionCmd{
 			System: forSystem,
 			Cell:   s.Cell,
 			Block:  s.Block,
 			Target: s.Target,
 			Action: s.Action}
-		if err := command.Exec(args[1:]); err != nil {
+		sources, err := flake.Sources()
+		if err != nil {
+			return err
+		}
+		w, err := watch.New(watchDebounce, sources...)
+		if err != nil {
 			return err
 		}
+		defer w.Close()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		watchAction(ctx, w, command, args[1:])
 		return nil
-
 	},
 }
+
+// watchAction runs the action and re-runs it on every change reported by the
+// watcher, interrupting the run still going on, until the context is done.
+func watchAction(ctx context.Context, w *watch.Watcher, command *flake.RunActionCmd, args []string) {
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			code, err := runActionContext(runCtx, command, args, history.SourceWatch)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", command.Spec(), errorReport(err))
+			} else if runCtx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "%s exited with %d, waiting for changes\n", command.Spec(), code)
+			}
+		}()
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return
+		case files, ok := <-w.Changes:
+			cancel()
+			<-done
+			if !ok {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s, re-running %s\n", describeChanges(files), command.Spec())
+		}
+	}
+}
+
 var reCacheCmd = &cobra.Command{
 	Use:   "re-cache",
 	Short: "Refresh the CLI cache.",
@@ -79,181 +382,826 @@ The TUI does this automatically, but the command completion needs manual initial
 			return fmt.Errorf("while loading flake (cmd '%v'): %w", loadCmd, err)
 		}
 		loadCmd.Run()
-		c.PutBytes(*key, buf.Bytes())
+		putMetadata(c, key, buf.Bytes())
 		return nil
 	},
 }
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and maintain the metadata cache.",
+	Long: fmt.Sprintf(`Inspect and maintain the metadata cache.
+The metadata is cached by the content of the flake's sources. Entries that weren't used for %s
+are trimmed whenever the flake is evaluated, at most once a day.`, cache.DefaultTrimLimit),
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:           "stats",
+	Short:         "Show the number, size and age of the cache entries.",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := flake.OpenCache()
+		if err != nil {
+			return err
+		}
+		records, err := c.Records()
+		if err != nil {
+			return err
+		}
+		size, err := c.DiskUsage()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 5, 2, 2, ' ', 0)
+		fmt.Fprintf(w, "cache:\t%s\n", c.Dir())
+		fmt.Fprintf(w, "entries:\t%d\n", len(records))
+		fmt.Fprintf(w, "size:\t%s\n", cache.FormatSize(size))
+		if len(records) > 0 {
+			fmt.Fprintln(w, "last used:")
+			counts := make([]int, len(ageBuckets))
+			for _, r := range records {
+				for i, b := range ageBuckets {
+					if time.Since(r.Used) < b.max || i == len(ageBuckets)-1 {
+						counts[i]++
+						break
+					}
+				}
+			}
+			for i, b := range ageBuckets {
+				bar := strings.Repeat("█", (counts[i]*30+len(records)-1)/len(records))
+				fmt.Fprintf(w, "  %s\t%d\t%s\n", b.label, counts[i], bar)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// ageBuckets are the buckets of the histogram of when cache entries were used last.
+var ageBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"< 1 hour", time.Hour},
+	{"< 1 day", 24 * time.Hour},
+	{"< 1 week", 7 * 24 * time.Hour},
+	{"< 30 days", 30 * 24 * time.Hour},
+	{">= 30 days", 0},
+}
+
+var cacheTrimCmd = &cobra.Command{
+	Use:   "trim",
+	Short: "Remove the cache entries that weren't used for a while.",
+	Long: `Remove the cache entries that weren't used for a while.
+Times of use are recorded with a precision of an hour, so entries may be kept for up to an hour longer.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := flake.OpenCache()
+		if err != nil {
+			return err
+		}
+		entries, size := c.TrimNow(cacheOlder)
+		fmt.Printf("removed %d entries (%s)\n", entries, cache.FormatSize(size))
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:           "clear",
+	Short:         "Remove all cache entries.",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := flake.OpenCache()
+		if err != nil {
+			return err
+		}
+		entries, size := c.Clear()
+		fmt.Printf("removed %d entries (%s)\n", entries, cache.FormatSize(size))
+		return nil
+	},
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:   "show [KEY]",
+	Short: "Show a cache entry.",
+	Long: `Show a cache entry and its content.
+The key is the entry's hex key or an unambiguous prefix of it. Without a key, the entry
+of the metadata of the flake's current sources is shown.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			c   *cache.Cache
+			key string
+			err error
+		)
+		if len(args) == 0 {
+			var id *cache.ActionID
+			if c, id, _, _, err = flake.LoadFlakeCmd(); err != nil {
+				return err
+			}
+			if id == nil {
+				return errNoCache
+			}
+			key = fmt.Sprintf("%x", *id)
+		} else {
+			if c, err = flake.OpenCache(); err != nil {
+				return err
+			}
+			key = args[0]
+		}
+		record, err := c.Find(key)
+		if err != nil {
+			if len(args) == 0 {
+				return fmt.Errorf("the current metadata isn't cached, see '%s re-cache'", argv0)
+			}
+			return err
+		}
+		content, _, err := c.GetBytes(record.ID)
+		if err != nil {
+			return err
+		}
+		if !cacheShowRaw {
+			w := tabwriter.NewWriter(os.Stdout, 5, 2, 2, ' ', 0)
+			fmt.Fprintf(w, "key:\t%x\n", record.ID)
+			fmt.Fprintf(w, "output:\t%x\n", record.OutputID)
+			fmt.Fprintf(w, "size:\t%s\n", cache.FormatSize(record.Size))
+			fmt.Fprintf(w, "created:\t%s\n", record.Time.Format(time.RFC3339))
+			fmt.Fprintf(w, "used:\t%s\n", record.Used.Format(time.RFC3339))
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Validate the repository.",
 	Long: fmt.Sprintf(`Validates that the repository conforms to %[1]s.
 Returns a non-zero exit code and an error message if the repository is not a valid %[1]s repository.
-The TUI does this automatically.`, project),
+The TUI does this automatically.
+
+The metadata is then linted, e.g. for targets without a description or readme, or dependencies
+on unknown targets. Any finding with the severity 'error' fails the check. The severity of each
+rule can be set in the '[lint]' table of the config or with '--severity', e.g. 'missing-readme=off'.
+Rules: %[2]s.
+
+The metadata is loaded leniently, like everywhere else. With '--schema', it's validated against
+its schema, too, and the violations are reported like lint findings, each with its JSON path.
+
+With '--profile', the evaluations of loading the metadata are timed separately and every cell
+is evaluated on its own, to find what makes startup slow. The report goes to stderr. With
+'--eval-stats', it includes nix's evaluation statistics, too.`, project, strings.Join(lint.RuleIDs(), ", ")),
 	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat(checkOutput, checkOutputFormats)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, _, loadCmd, _, err := flake.LoadFlakeCmd()
-		loadCmd.Args = append(loadCmd.Args, "--trace-verbose")
+		overrides := map[string]string{}
+		for rule, severity := range config.Get().Lint {
+			overrides[rule] = severity
+		}
+		for rule, severity := range checkSeverity {
+			overrides[rule] = severity
+		}
+		severities, err := lint.ResolveSeverities(overrides)
+		if err != nil {
+			return err
+		}
+		_, _, loadCmd, buf, err := flake.LoadFlakeCmd()
 		if err != nil {
 			return fmt.Errorf("while loading flake (cmd '%v'): %w", loadCmd, err)
 		}
+		loadCmd.Args = append(loadCmd.Args, "--trace-verbose")
 		loadCmd.Stderr = os.Stderr
 		if err := loadCmd.Run(); err != nil {
 			os.Exit(1)
 		}
-		fmt.Printf("Valid %s repository ✓\n", project)
-
+		root, err := data.Parse(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		version := root.Version
+		var findings []lint.Finding
+		if checkSchema {
+			v, violations, err := data.Validate(buf.Bytes())
+			if err != nil {
+				return err
+			}
+			version = v
+			findings = lint.SchemaFindings(violations)
+		}
+		findings = append(findings, lint.Run(root, severities)...)
+		if err := writeFindings(os.Stdout, checkOutput, findings); err != nil {
+			return err
+		}
+		failed := lint.Count(findings)[lint.Error] > 0
+		if !failed && checkOutput == outputText {
+			fmt.Printf("Valid %s repository, metadata schema version %d ✓\n", project, version)
+		}
+		if checkProfile || checkEvalStats {
+			profile, err := flake.NewProfile(flake.ProfileOptions{Stats: checkEvalStats, CellNames: data.CellNames})
+			if err != nil {
+				return fmt.Errorf("while profiling the evaluation: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "\nEvaluation profile:\n%s", profile.Report())
+		}
+		if failed {
+			os.Exit(1)
+		}
 		return nil
 	},
 }
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available targets.",
 	Long: `List available targets.
 Shows a list of all available targets. Can be used as an alternative to the TUI.
-Also loads the CLI cache, if no cache is found. Reads the cache, otherwise.`,
-	Args: cobra.NoArgs,
+Also loads the CLI cache, if no cache is found. Reads the cache, otherwise.
+
+Optionally select targets with glob patterns, e.g. '//backend/*/api*' or '//*/*/*:build',
+and narrow them down further with the filter flags, or with a query like the TUI's filter,
+e.g. --query 'type:containers action:publish api', which fuzzily matches every word against
+the targets' titles, descriptions, block types and action names, or only against the field
+it's qualified with (title:, descr:, type: or action:).
+
+With '--output json|yaml' the full tree of cells, blocks, targets and actions is emitted.
+With '--output ndjson' one json object per action is emitted on its own line.`,
+	Args: cobra.ArbitraryArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat(listOutput, listOutputFormats)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cache, key, loadCmd, buf, err := flake.LoadFlakeCmd()
+		f, err := listFilter(cmd, args)
 		if err != nil {
-			return fmt.Errorf("while loading flake (cmd '%v'): %w", loadCmd, err)
+			return err
 		}
-		cached, _, err := cache.GetBytes(*key)
-		var root *data.Root
-		if err == nil {
-			root, err = LoadJson(bytes.NewReader(cached))
-			if err != nil {
-				return fmt.Errorf("while loading cached json: %w", err)
+		root, err := LoadRoot()
+		if err != nil {
+			return err
+		}
+		return writeList(os.Stdout, listOutput, f.Apply(root))
+	},
+}
+
+var runManyCmd = &cobra.Command{
+	Use:   "run-many SPEC... [-- args...]",
+	Short: "Run several actions at once.",
+	Long: `Run several actions at once.
+Selects actions with spec patterns like in 'list', e.g. '//backend/*/*:build', builds all of them
+with a single nix invocation and then executes them, either one after the other or in parallel.
+The output of each action is prefixed with its spec and a summary is printed at the end.
+Arguments after '--' are passed to every action.`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var extraArgs []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, extraArgs = args[:dash], args[dash:]
+		}
+		cmds, err := selectActions(args)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		batch := flake.Batch{
+			Cmds:     cmds,
+			Parallel: runManyParallel,
+			FailFast: runManyFailFast,
+			Stdout:   os.Stdout,
+			Stderr:   os.Stderr,
+		}
+		results, err := batch.Run(ctx, extraArgs)
+		if err != nil {
+			return err
+		}
+		return printBatchSummary(os.Stderr, results)
+	},
+}
+
+// selectActions expands spec patterns into the commands of the selected actions.
+func selectActions(patterns []string) ([]flake.RunActionCmd, error) {
+	f, err := filter.New(patterns...)
+	if err != nil {
+		return nil, err
+	}
+	root, err := LoadRoot()
+	if err != nil {
+		return nil, err
+	}
+	var cmds []flake.RunActionCmd
+	for _, rec := range f.Apply(root).ActionRecords() {
+		cmds = append(cmds, flake.RunActionCmd{
+			System: forSystem,
+			Cell:   rec.Cell,
+			Block:  rec.Block,
+			Target: rec.Target,
+			Action: rec.Action,
+		})
+	}
+	if len(cmds) == 0 {
+		return nil, fmt.Errorf("no actions match %v", patterns)
+	}
+	return cmds, nil
+}
+
+// printBatchSummary prints the outcome of each action and
+// returns an error if any of them failed or was skipped.
+func printBatchSummary(w io.Writer, results []flake.BatchResult) error {
+	var failed, skipped int
+	tw := tabwriter.NewWriter(w, 5, 2, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+			fmt.Fprintf(tw, "-\t%s\tskipped\n", r.Cmd.Spec())
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(tw, "✗\t%s\t%v\n", r.Cmd.Spec(), r.Err)
+		case r.ExitCode != 0:
+			failed++
+			fmt.Fprintf(tw, "✗\t%s\texit code %d after %s\n", r.Cmd.Spec(), r.ExitCode, r.Duration.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(tw, "✓\t%s\t%s\n", r.Cmd.Spec(), r.Duration.Round(time.Millisecond))
+		}
+	}
+	tw.Flush()
+	switch {
+	case failed > 0 && skipped > 0:
+		return fmt.Errorf("%d of %d actions failed, %d skipped", failed, len(results), skipped)
+	case failed > 0:
+		return fmt.Errorf("%d of %d actions failed", failed, len(results))
+	case skipped > 0:
+		return fmt.Errorf("%d of %d actions skipped", skipped, len(results))
+	}
+	return nil
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [SPEC...]",
+	Short: "Show previously run actions.",
+	Long: fmt.Sprintf(`Show previously run actions, most recent first.
+Lists every action run from the CLI or the TUI, optionally only those matching the spec patterns.
+Re-run an entry with '%[1]s rerun N'.`, argv0),
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := filter.New(args...)
+		if err != nil {
+			return err
+		}
+		entries, err := history.Load()
+		if err != nil {
+			return err
+		}
+		selected := history.Select(entries, func(e history.Entry) bool {
+			if historyFailed && e.ExitCode == 0 {
+				return false
 			}
-		} else {
-			loadCmd.Run()
-			bufA := &bytes.Buffer{}
-			r := io.TeeReader(buf, bufA)
-			root, err = LoadJson(r)
-			if err != nil {
-				return fmt.Errorf("while loading json (cmd: '%v'): %w", loadCmd, err)
+			return f.MatchAction(data.Cell{Name: e.Cell}, data.Block{Name: e.Block}, data.Target{Name: e.Target}, data.Action{Name: e.Action})
+		}, historyLimit)
+		if historyJson {
+			enc := json.NewEncoder(os.Stdout)
+			for _, e := range selected {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
 			}
-			cache.PutBytes(*key, bufA.Bytes())
+			return nil
 		}
-		w := tabwriter.NewWriter(os.Stdout, 5, 2, 4, ' ', 0)
-		for _, c := range root.Cells {
-			for _, o := range c.Blocks {
-				for _, t := range o.Targets {
-					for _, a := range t.Actions {
-						fmt.Fprintf(w, "//%s/%s/%s:%s\t--\t%s:  %s\n", c.Name, o.Name, t.Name, a.Name, t.Description(), a.Description())
-					}
-				}
+		w := tabwriter.NewWriter(os.Stdout, 5, 2, 2, ' ', 0)
+		for _, e := range selected {
+			status := "✓"
+			if e.ExitCode != 0 {
+				status = fmt.Sprintf("✗ %d", e.ExitCode)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", e.N, e.Start.Local().Format(time.Stamp), status, e.Duration().Round(time.Millisecond), e.CommandLine(argv0))
+		}
+		return w.Flush()
+	},
+}
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun [N] [-- args...]",
+	Short: "Re-run a previously run action.",
+	Long: fmt.Sprintf(`Re-run a previously run action.
+Re-runs the N-th most recent entry of '%[1]s history' (default: the last one) with the same arguments.
+Arguments after '--' replace the recorded ones.`, argv0),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args = args[:dash]
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			n         = 1
+			extraArgs []string
+			override  bool
+		)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, extraArgs, override = args[:dash], args[dash:], true
+		}
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid history entry: %s", args[0])
+			}
+		}
+		entries, err := history.Load()
+		if err != nil {
+			return err
+		}
+		e, err := history.Nth(entries, n)
+		if err != nil {
+			return err
+		}
+		if !override {
+			extraArgs = e.Args
+		}
+		code, err := runAction(e.Cmd(), extraArgs, history.SourceCli)
+		if err != nil {
+			return err
+		}
+		os.Exit(code)
+		return nil
+	},
+}
+
+var depsCmd = &cobra.Command{
+	Use:   "deps [//cell/block/target]",
+	Short: "Show the dependency graph of targets.",
+	Long: `Show the dependency graph of targets, as declared by their 'deps'.
+Given a target, prints the tree of its dependencies and the tree of its dependents.
+With '--format dot|mermaid|json' the graph is exported instead: the whole graph, or, given
+a target, the part of it that is connected to the target.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(depsFormat, depsFormats); err != nil {
+			return err
+		}
+		if depsFormat == depsTree && len(args) == 0 {
+			return fmt.Errorf("a target is required for the '%s' format", depsTree)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := LoadRoot()
+		if err != nil {
+			return err
+		}
+		g := graph.New(root)
+		if len(args) > 0 {
+			id := g.Resolve(args[0])
+			if !g.Has(id) {
+				return fmt.Errorf("unknown target: %s", args[0])
+			}
+			if depsFormat == depsTree {
+				fmt.Println("Dependencies:")
+				g.WriteTree(os.Stdout, id, false)
+				fmt.Println("\nDependents:")
+				g.WriteTree(os.Stdout, id, true)
+				return nil
 			}
+			g = g.Sub(append(g.Closure(id, false), g.Closure(id, true)...))
+		}
+		switch depsFormat {
+		case depsDot:
+			g.WriteDot(os.Stdout)
+		case depsMermaid:
+			g.WriteMermaid(os.Stdout)
+		case outputJson:
+			return g.WriteJson(os.Stdout)
 		}
-		w.Flush()
 		return nil
 	},
 }
 
+// listFilter assembles the filter from the spec patterns and the filter flags.
+func listFilter(cmd *cobra.Command, args []string) (*filter.Filter, error) {
+	f, err := filter.New(args...)
+	if err != nil {
+		return nil, err
+	}
+	f.BlockType = listBlockType
+	f.Action = listAction
+	if listQuery != "" {
+		q, err := filter.ParseQuery(listQuery)
+		if err != nil {
+			return nil, err
+		}
+		f.Specs = append(f.Specs, q.Specs...)
+		f.Terms = q.Terms
+	}
+	if cmd.Flags().Changed("has-readme") {
+		f.HasReadme = &listHasReadme
+	}
+	return f, f.Validate()
+}
+
 func ExecuteCli() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Println(errorReport(err))
 		os.Exit(1)
 	}
 }
 
+// errorReport describes the error, in detail for evaluation errors.
+func errorReport(err error) string {
+	var evalErr *flake.EvalError
+	if errors.As(err, &evalErr) {
+		return strings.TrimSuffix(evalErr.Report(), "\n")
+	}
+	return err.Error()
+}
+
 func init() {
 	rootCmd.Flags().StringVar(&forSystem, "for", "", "system, for which the target will be built (e.g. 'x86_64-linux')")
+	rootCmd.Flags().StringVar(&tuiTheme, "theme", config.ThemeAuto, fmt.Sprintf("theme of the TUI, a theme file or one of %v", styles.ThemeNames()))
+	rootCmd.Flags().BoolVar(&tuiWatch, "watch", false, "reload the TUI's targets whenever the flake's sources change")
+	rootCmd.Flags().BoolVar(&tuiStayOpen, "stay-open", false, "run actions in the TUI's output pane instead of quitting it")
+	watchCmd.Flags().StringVar(&forSystem, "for", "", "system, for which the target will be built (e.g. 'x86_64-linux')")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", watch.DefaultDebounce, "how long changes must have settled before re-running")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", outputTable, fmt.Sprintf("output format, one of %v", listOutputFormats))
+	listCmd.Flags().StringVar(&listBlockType, "block-type", "", "only list targets of a block type (glob, e.g. 'containers')")
+	listCmd.Flags().StringVar(&listAction, "action", "", "only list actions of that name (glob, e.g. 'build')")
+	listCmd.Flags().BoolVar(&listHasReadme, "has-readme", true, "only list targets with (or, if false, without) a readme")
+	listCmd.Flags().StringVar(&listQuery, "query", "", "only list targets matching a fuzzy query (e.g. 'type:containers api')")
+	for _, cmd := range []*cobra.Command{rootCmd, runCmd} {
+		cmd.Flags().BoolVar(&runWithDeps, "with-deps", false, "run the same-named action of all dependencies first")
+		cmd.Flags().BoolVar(&runKeepGoing, "keep-going", false, "with '--with-deps', keep running actions that don't depend on a failed one")
+	}
+	runCmd.Flags().StringVar(&forSystem, "for", "", "system, for which the target will be built (e.g. 'x86_64-linux')")
+	runManyCmd.Flags().StringVar(&forSystem, "for", "", "system, for which the targets will be built (e.g. 'x86_64-linux')")
+	runManyCmd.Flags().IntVarP(&runManyParallel, "parallel", "j", 1, "number of actions to execute in parallel")
+	runManyCmd.Flags().BoolVar(&runManyFailFast, "fail-fast", false, "don't start any further actions once one failed")
+	historyCmd.Flags().BoolVar(&historyJson, "json", false, "print one json object per entry")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "show at most this many entries")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "only show failed invocations")
+	checkCmd.Flags().BoolVar(&checkSchema, "schema", false, "report violations of the metadata schema as findings")
+	checkCmd.Flags().BoolVar(&checkProfile, "profile", false, "time the evaluation of the metadata, step by step and cell by cell")
+	checkCmd.Flags().BoolVar(&checkEvalStats, "eval-stats", false, "report nix's evaluation statistics in the profile, implies '--profile'")
+	checkCmd.Flags().StringVarP(&checkOutput, "output", "o", outputText, fmt.Sprintf("output format, one of %v", checkOutputFormats))
+	checkCmd.Flags().StringToStringVar(&checkSeverity, "severity", nil, fmt.Sprintf("set the severity of a lint rule, one of %v (e.g. 'missing-readme=off')", lint.Severities))
+	depsCmd.Flags().StringVar(&depsFormat, "format", depsTree, fmt.Sprintf("output format, one of %v", depsFormats))
+	cacheTrimCmd.Flags().DurationVar(&cacheOlder, "older-than", cache.DefaultTrimLimit, "remove entries that weren't used for this long (e.g. '72h')")
+	cacheShowCmd.Flags().BoolVar(&cacheShowRaw, "raw", false, "only show the content")
 	rootCmd.AddCommand(reCacheCmd)
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(depsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rerunCmd)
+	rootCmd.AddCommand(runManyCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(watchCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheTrimCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheShowCmd)
+	rootCmd.AddCommand(cacheCmd)
 	carapace.Gen(rootCmd).Standalone()
+	carapace.Gen(listCmd).FlagCompletion(carapace.ActionMap{
+		"output": carapace.ActionValues(listOutputFormats...),
+		"block-type": carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			root, err := LoadCachedRoot()
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			var types = map[string]bool{}
+			for _, c := range root.Cells {
+				for _, b := range c.Blocks {
+					types[b.Blocktype] = true
+				}
+			}
+			return carapace.ActionValues(keysOf(types)...)
+		}),
+		"action": carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			root, err := LoadCachedRoot()
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			var names = map[string]bool{}
+			for _, rec := range root.ActionRecords() {
+				names[rec.Action] = true
+			}
+			return carapace.ActionValues(keysOf(names)...)
+		}),
+	})
+	carapace.Gen(rootCmd).FlagCompletion(carapace.ActionMap{
+		"theme": carapace.Batch(
+			carapace.ActionValues(styles.ThemeNames()...),
+			carapace.ActionFiles(".toml"),
+		).ToA(),
+	})
 	// completes: '//cell/block/target:action'
 	carapace.Gen(rootCmd).PositionalCompletion(
 		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
-			cache, key, _, _, err := flake.LoadFlakeCmd()
+			return actionSpecs(nil)
+		}),
+	)
+	carapace.Gen(runCmd).PositionalCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			return actionSpecs(nil)
+		}),
+	)
+	carapace.Gen(watchCmd).PositionalCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			return actionSpecs(nil)
+		}),
+	)
+	carapace.Gen(cacheShowCmd).PositionalCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			c, err := flake.OpenCache()
 			if err != nil {
-				return carapace.ActionMessage(fmt.Sprintf("%v\n", err))
-			}
-			cached, _, err := cache.GetBytes(*key)
-			var root *data.Root
-			if err == nil {
-				root, err = LoadJson(bytes.NewReader(cached))
-				if err != nil {
-					return carapace.ActionMessage(fmt.Sprintf("%v\n", err))
+				return carapace.ActionMessage(err.Error())
+			}
+			records, err := c.Records()
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			var values []string
+			for _, r := range records {
+				values = append(values, fmt.Sprintf("%x", r.ID[:6]), fmt.Sprintf("%s, used %s", cache.FormatSize(r.Size), r.Used.Format(time.RFC3339)))
+			}
+			return carapace.ActionValuesDescribed(values...)
+		}),
+	)
+	carapace.Gen(runManyCmd).PositionalAnyCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			return actionSpecs(nil)
+		}),
+	)
+	carapace.Gen(checkCmd).FlagCompletion(carapace.ActionMap{
+		"output": carapace.ActionValues(checkOutputFormats...),
+		"severity": carapace.ActionMultiParts("=", func(c carapace.Context) carapace.Action {
+			switch len(c.Parts) {
+			case 0:
+				return carapace.ActionValues(lint.RuleIDs()...).Suffix("=")
+			case 1:
+				var severities []string
+				for _, s := range lint.Severities {
+					severities = append(severities, string(s))
 				}
-			} else {
-				return carapace.ActionMessage(fmt.Sprintf("No completion cache: please initialize by running '%[1]s re-cache'.", argv0))
+				return carapace.ActionValues(severities...)
 			}
-			var cells = []string{}
-			var blocks = map[string][]string{}
-			var targets = map[string]map[string][]string{}
-			var actions = map[string]map[string]map[string][]string{}
-			for _, c := range root.Cells {
-				blocks[c.Name] = []string{}
-				targets[c.Name] = map[string][]string{}
-				actions[c.Name] = map[string]map[string][]string{}
-				cells = append(cells, c.Name, "cell")
-				for _, b := range c.Blocks {
-					targets[c.Name][b.Name] = []string{}
-					actions[c.Name][b.Name] = map[string][]string{}
-					blocks[c.Name] = append(blocks[c.Name], b.Name, "block")
-					for _, t := range b.Targets {
-						actions[c.Name][b.Name][t.Name] = []string{}
-						targets[c.Name][b.Name] = append(targets[c.Name][b.Name], t.Name, t.Description())
-						for _, a := range t.Actions {
-							actions[c.Name][b.Name][t.Name] = append(
-								actions[c.Name][b.Name][t.Name],
-								a.Name,
-								a.Description(),
-							)
-						}
+			return carapace.ActionValues()
+		}),
+	})
+	carapace.Gen(depsCmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues(depsFormats...),
+	})
+	carapace.Gen(depsCmd).PositionalCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			root, err := LoadCachedRoot()
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			var targets []string
+			for ci, c := range root.Cells {
+				for bi, b := range c.Blocks {
+					for ti := range b.Targets {
+						targets = append(targets, root.TargetTitle(ci, bi, ti), root.TargetDescription(ci, bi, ti))
 					}
 				}
 			}
-			return carapace.ActionMultiParts("/", func(c carapace.Context) carapace.Action {
-				switch len(c.Parts) {
-				// start with <tab>; no typing
+			return carapace.ActionValuesDescribed(targets...)
+		}),
+	)
+	carapace.Gen(historyCmd).PositionalAnyCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			return actionSpecs(nil)
+		}),
+	)
+	carapace.Gen(rerunCmd).PositionalCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			entries, err := history.Load()
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			var values []string
+			for i, e := range entries {
+				values = append(values, strconv.Itoa(i+1), e.CommandLine(argv0))
+			}
+			return carapace.ActionValuesDescribed(values...)
+		}),
+	)
+	carapace.Gen(listCmd).PositionalAnyCompletion(
+		carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+			f, err := listFilter(listCmd, nil)
+			if err != nil {
+				return carapace.ActionMessage(err.Error())
+			}
+			return actionSpecs(f)
+		}),
+	)
+}
+
+func keysOf(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// actionSpecs completes '//cell/block/target:action' specs from the CLI cache,
+// offering only what is selected by the filter.
+func actionSpecs(f *filter.Filter) carapace.Action {
+	root, err := LoadCachedRoot()
+	if errors.Is(err, errNoCache) {
+		return carapace.ActionMessage(fmt.Sprintf("No completion cache: please initialize by running '%[1]s re-cache'.", argv0))
+	} else if err != nil {
+		return carapace.ActionMessage(fmt.Sprintf("%v\n", err))
+	}
+	root = f.Apply(root)
+	var cells = []string{}
+	var blocks = map[string][]string{}
+	var targets = map[string]map[string][]string{}
+	var actions = map[string]map[string]map[string][]string{}
+	for _, c := range root.Cells {
+		blocks[c.Name] = []string{}
+		targets[c.Name] = map[string][]string{}
+		actions[c.Name] = map[string]map[string][]string{}
+		cells = append(cells, c.Name, "cell")
+		for _, b := range c.Blocks {
+			targets[c.Name][b.Name] = []string{}
+			actions[c.Name][b.Name] = map[string][]string{}
+			blocks[c.Name] = append(blocks[c.Name], b.Name, "block")
+			for _, t := range b.Targets {
+				actions[c.Name][b.Name][t.Name] = []string{}
+				targets[c.Name][b.Name] = append(targets[c.Name][b.Name], t.Name, t.Description())
+				for _, a := range t.Actions {
+					actions[c.Name][b.Name][t.Name] = append(
+						actions[c.Name][b.Name][t.Name],
+						a.Name,
+						a.Description(),
+					)
+				}
+			}
+		}
+	}
+	return carapace.ActionMultiParts("/", func(c carapace.Context) carapace.Action {
+		switch len(c.Parts) {
+		// start with <tab>; no typing
+		case 0:
+			return carapace.ActionValuesDescribed(
+				cells...,
+			).Invoke(c).Prefix("//").Suffix("/").ToA().Style(
+				style.Of(style.Bold, style.Carapace.Highlight(1)))
+		// only a single / typed
+		case 1:
+			return carapace.ActionValuesDescribed(
+				cells...,
+			).Invoke(c).Prefix("/").Suffix("/").ToA()
+		// start typing cell
+		case 2:
+			return carapace.ActionValuesDescribed(
+				cells...,
+			).Invoke(c).Suffix("/").ToA().Style(
+				style.Carapace.Highlight(1))
+		// start typing block
+		case 3:
+			return carapace.ActionValuesDescribed(
+				blocks[c.Parts[2]]...,
+			).Invoke(c).Suffix("/").ToA().Style(
+				style.Carapace.Highlight(2))
+		// start typing target
+		case 4:
+			return carapace.ActionMultiParts(":", func(d carapace.Context) carapace.Action {
+				switch len(d.Parts) {
+				// start typing target
 				case 0:
 					return carapace.ActionValuesDescribed(
-						cells...,
-					).Invoke(c).Prefix("//").Suffix("/").ToA().Style(
-						style.Of(style.Bold, style.Carapace.Highlight(1)))
-				// only a single / typed
+						targets[c.Parts[2]][c.Parts[3]]...,
+					).Invoke(c).Suffix(":").ToA().Style(
+						style.Carapace.Highlight(3))
+					// start typing action
 				case 1:
 					return carapace.ActionValuesDescribed(
-						cells...,
-					).Invoke(c).Prefix("/").Suffix("/").ToA()
-				// start typing cell
-				case 2:
-					return carapace.ActionValuesDescribed(
-						cells...,
-					).Invoke(c).Suffix("/").ToA().Style(
-						style.Carapace.Highlight(1))
-				// start typing block
-				case 3:
-					return carapace.ActionValuesDescribed(
-						blocks[c.Parts[2]]...,
-					).Invoke(c).Suffix("/").ToA().Style(
-						style.Carapace.Highlight(2))
-				// start typing target
-				case 4:
-					return carapace.ActionMultiParts(":", func(d carapace.Context) carapace.Action {
-						switch len(d.Parts) {
-						// start typing target
-						case 0:
-							return carapace.ActionValuesDescribed(
-								targets[c.Parts[2]][c.Parts[3]]...,
-							).Invoke(c).Suffix(":").ToA().Style(
-								style.Carapace.Highlight(3))
-							// start typing action
-						case 1:
-							return carapace.ActionValuesDescribed(
-								actions[c.Parts[2]][c.Parts[3]][d.Parts[0]]...,
-							).Invoke(c).ToA()
-						default:
-							return carapace.ActionValues()
-						}
-					})
+						actions[c.Parts[2]][c.Parts[3]][d.Parts[0]]...,
+					).Invoke(c).ToA()
 				default:
 					return carapace.ActionValues()
 				}
 			})
-
-		}),
-	)
+		default:
+			return carapace.ActionValues()
+		}
+	})
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paisano-nix/paisano/flake"
+)
+
+func TestPrintBatchSummary(t *testing.T) {
+	cmd := func(target string) flake.RunActionCmd {
+		return flake.RunActionCmd{Cell: "backend", Block: "apps", Target: target, Action: "build"}
+	}
+	var (
+		ok      = flake.BatchResult{Cmd: cmd("api"), Duration: 1500 * time.Millisecond}
+		failed  = flake.BatchResult{Cmd: cmd("worker"), ExitCode: 3, Duration: time.Second}
+		broken  = flake.BatchResult{Cmd: cmd("lib"), ExitCode: -1, Err: errors.New("no such file")}
+		skipped = flake.BatchResult{Cmd: cmd("web"), Skipped: true}
+	)
+	for _, tc := range []struct {
+		name    string
+		results []flake.BatchResult
+		out     string
+		err     string
+	}{
+		{"success", []flake.BatchResult{ok}, "\n✓    //backend/apps/api:build  1.5s\n", ""},
+		{"failed", []flake.BatchResult{ok, failed, broken},
+			"\n" +
+				"✓    //backend/apps/api:build     1.5s\n" +
+				"✗    //backend/apps/worker:build  exit code 3 after 1s\n" +
+				"✗    //backend/apps/lib:build     no such file\n",
+			"2 of 3 actions failed"},
+		{"failed and skipped", []flake.BatchResult{failed, skipped, skipped}, "", "1 of 3 actions failed, 2 skipped"},
+		{"skipped", []flake.BatchResult{ok, skipped}, "", "1 of 2 actions skipped"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := printBatchSummary(&buf, tc.results)
+			if tc.out != "" && buf.String() != tc.out {
+				t.Errorf("got output\n%s\nwant\n%s", buf.String(), tc.out)
+			}
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("got error %q, want %q", got, tc.err)
+			}
+		})
+	}
+}
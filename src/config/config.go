@@ -0,0 +1,257 @@
+// Package config resolves paisano's settings from the project's
+// '.paisano.toml' and the user's config file, which takes precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/adrg/xdg"
+)
+
+const (
+	// ProjectFile is the name of the config file at the project root.
+	ProjectFile = ".paisano.toml"
+	// Default is the source of settings that aren't set in any file.
+	Default = "default"
+)
+
+const (
+	NomAuto   = "auto"
+	NomAlways = "always"
+	NomNever  = "never"
+)
+
+const (
+	ThemeAuto         = "auto"
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+)
+
+var (
+	NomModes = []string{NomAuto, NomAlways, NomNever}
+	Themes   = []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeMonochrome}
+)
+
+type Config struct {
+	// Registry is the flake output holding the paisano registry.
+	Registry string `toml:"registry"`
+	// NixFlags are passed to every nix invocation evaluating or building the flake.
+	NixFlags []string `toml:"nix-flags"`
+	// Nom tells whether actions are built with 'nom' instead of 'nix'.
+	Nom string `toml:"nom"`
+	// System is the default of the '--for' flag, which must be another
+	// system than the current one.
+	System string `toml:"system"`
+	// Theme selects the TUI colors: one of Themes or the path of a theme file.
+	Theme string `toml:"theme"`
+	// StayOpen runs actions from the TUI in its output pane instead of
+	// quitting the TUI to execute them. They don't run on a terminal there.
+	StayOpen bool `toml:"stay-open"`
+	// KeymapFile holds a keymap preset and bindings for the TUI.
+	KeymapFile string `toml:"keymap-file"`
+	// Keymap rebinds TUI key bindings, by their name, on top of the keymap file.
+	Keymap map[string][]string `toml:"keymap"`
+	// Lint sets the severity of 'check' lint rules, by their id.
+	Lint map[string]string `toml:"lint"`
+
+	// Sources maps each setting to the file it was set in, or Default.
+	Sources map[string]string `toml:"-"`
+}
+
+// Defaults returns the settings used in the absence of any config file.
+func Defaults() *Config {
+	return &Config{
+		Registry:   "__std", // keep for now for historic reasons
+		NixFlags:   []string{},
+		Nom:        NomAuto,
+		Theme:      ThemeAuto,
+		KeymapFile: filepath.Join(xdg.ConfigHome, "paisano", "keymap.toml"),
+		Keymap:     map[string][]string{},
+		Lint:       map[string]string{},
+		Sources: map[string]string{
+			"registry":    Default,
+			"nix-flags":   Default,
+			"nom":         Default,
+			"system":      Default,
+			"theme":       Default,
+			"stay-open":   Default,
+			"keymap-file": Default,
+		},
+	}
+}
+
+// Paths returns the project's and the user's config file, in order of precedence.
+func Paths() []string {
+	// the flake is always evaluated from the working directory, so
+	// that's the project root in the absence of $PRJ_ROOT
+	root := os.Getenv("PRJ_ROOT")
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	return []string{
+		filepath.Join(root, ProjectFile),
+		filepath.Join(xdg.ConfigHome, "paisano", "config.toml"),
+	}
+}
+
+var (
+	once    sync.Once
+	current *Config
+	loadErr error
+)
+
+// Load resolves the config from the files in Paths once and returns it.
+func Load() (*Config, error) {
+	once.Do(func() {
+		current, loadErr = LoadFrom(Paths()...)
+	})
+	return current, loadErr
+}
+
+// Get returns the resolved config, or the defaults if it can't be loaded.
+func Get() *Config {
+	if c, err := Load(); err == nil {
+		return c
+	}
+	return Defaults()
+}
+
+// LoadFrom resolves the config from the given files, where later files
+// override earlier ones. Missing files are skipped.
+func LoadFrom(paths ...string) (*Config, error) {
+	c := Defaults()
+	for _, path := range paths {
+		if err := c.merge(path); err != nil {
+			return nil, err
+		}
+	}
+	return c, c.Validate()
+}
+
+func (c *Config) merge(path string) error {
+	var f Config
+	md, err := toml.DecodeFile(path, &f)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("while reading %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("while reading %s: unknown setting '%s'", path, undecoded[0])
+	}
+	set := func(key string, apply func()) {
+		if md.IsDefined(key) {
+			apply()
+			c.Sources[key] = path
+		}
+	}
+	set("registry", func() { c.Registry = f.Registry })
+	set("nix-flags", func() { c.NixFlags = f.NixFlags })
+	set("nom", func() { c.Nom = f.Nom })
+	set("system", func() { c.System = f.System })
+	set("theme", func() { c.Theme = f.Theme })
+	set("stay-open", func() { c.StayOpen = f.StayOpen })
+	set("keymap-file", func() { c.KeymapFile = f.KeymapFile })
+	for name, keys := range f.Keymap {
+		c.Keymap[name] = keys
+		c.Sources["keymap."+name] = path
+	}
+	for rule, severity := range f.Lint {
+		c.Lint[rule] = severity
+		c.Sources["lint."+rule] = path
+	}
+	return nil
+}
+
+// Validate checks that all settings have valid values.
+func (c *Config) Validate() error {
+	if c.Registry == "" {
+		return fmt.Errorf("'registry' must not be empty (%s)", c.Sources["registry"])
+	}
+	if !oneOf(c.Nom, NomModes) {
+		return fmt.Errorf("invalid 'nom' setting '%s' (%s), must be one of %v", c.Nom, c.Sources["nom"], NomModes)
+	}
+	if !oneOf(c.Theme, Themes) && !strings.HasSuffix(c.Theme, ".toml") {
+		return fmt.Errorf("invalid 'theme' setting '%s' (%s), must be a '.toml' theme file or one of %v", c.Theme, c.Sources["theme"], Themes)
+	}
+	for name, keys := range c.Keymap {
+		if len(keys) == 0 {
+			return fmt.Errorf("no keys for 'keymap.%s' (%s)", name, c.Sources["keymap."+name])
+		}
+	}
+	return nil
+}
+
+// Settings returns the names of all settings in a stable order, keymap
+// and lint entries last.
+func (c *Config) Settings() []string {
+	settings := []string{"registry", "nix-flags", "nom", "system", "theme", "stay-open", "keymap-file"}
+	var keymap, lint []string
+	for name := range c.Keymap {
+		keymap = append(keymap, "keymap."+name)
+	}
+	for rule := range c.Lint {
+		lint = append(lint, "lint."+rule)
+	}
+	sort.Strings(keymap)
+	sort.Strings(lint)
+	return append(append(settings, keymap...), lint...)
+}
+
+// Value returns the setting's value in toml syntax.
+func (c *Config) Value(setting string) string {
+	switch setting {
+	case "registry":
+		return quote(c.Registry)
+	case "nix-flags":
+		return quoteAll(c.NixFlags)
+	case "nom":
+		return quote(c.Nom)
+	case "system":
+		return quote(c.System)
+	case "theme":
+		return quote(c.Theme)
+	case "stay-open":
+		return fmt.Sprint(c.StayOpen)
+	case "keymap-file":
+		return quote(c.KeymapFile)
+	}
+	if name := strings.TrimPrefix(setting, "keymap."); name != setting {
+		return quoteAll(c.Keymap[name])
+	}
+	if rule := strings.TrimPrefix(setting, "lint."); rule != setting {
+		return quote(c.Lint[rule])
+	}
+	return ""
+}
+
+func quote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+func quoteAll(s []string) string {
+	quoted := make([]string, len(s))
+	for i := range s {
+		quoted[i] = quote(s[i])
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
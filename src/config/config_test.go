@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFrom(t *testing.T) {
+	project := writeFile(t, `
+registry = "__paisano"
+nom = "never"
+[keymap]
+quit = ["x"]
+[lint]
+missing-readme = "off"
+`)
+	user := writeFile(t, `
+nom = "always"
+system = "aarch64-darwin"
+stay-open = true
+`)
+	c, err := LoadFrom(project, filepath.Join(t.TempDir(), "missing.toml"), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Registry != "__paisano" || c.Sources["registry"] != project {
+		t.Errorf("registry = %q from %s", c.Registry, c.Sources["registry"])
+	}
+	if c.Nom != NomAlways || c.Sources["nom"] != user {
+		t.Errorf("nom = %q from %s, want the user's setting", c.Nom, c.Sources["nom"])
+	}
+	if c.Theme != ThemeAuto || c.Sources["theme"] != Default {
+		t.Errorf("theme = %q from %s, want the default", c.Theme, c.Sources["theme"])
+	}
+	if !c.StayOpen || c.Value("stay-open") != "true" {
+		t.Errorf("stay-open = %s from %s", c.Value("stay-open"), c.Sources["stay-open"])
+	}
+	if got := c.Value("keymap.quit"); got != `["x"]` {
+		t.Errorf("keymap.quit = %s", got)
+	}
+	if c.Lint["missing-readme"] != "off" || c.Sources["lint.missing-readme"] != project {
+		t.Errorf("lint = %v", c.Lint)
+	}
+}
+
+func TestLoadFromInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		`nom = "sometimes"`: "invalid 'nom' setting",
+		`colour = "red"`:    "unknown setting 'colour'",
+		`registry = ""`:     "'registry' must not be empty",
+	} {
+		_, err := LoadFrom(writeFile(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", content, err, want)
+		}
+	}
+}
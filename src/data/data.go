@@ -3,9 +3,16 @@ package data
 import (
 	"fmt"
 
+	"github.com/oriser/regroup"
+
 	"github.com/paisano-nix/paisano/flake"
 )
 
+// SpecRe matches the '//cell/block/target:action' spec of an action, as the
+// CLI takes it: only cell and block names can't contain '/', and only
+// action names can't contain ':'.
+var SpecRe = regroup.MustCompile(`^//(?P<cell>[^/]+)/(?P<block>[^/]+)/(?P<target>.+):(?P<action>[^:]+)`)
+
 var (
 	targetTemplate = "//%s/%s/%s"
 	actionTemplate = "//%s/%s/%s:%s"
@@ -14,7 +21,9 @@ var (
 )
 
 type Root struct {
-	Cells []Cell
+	// Version is the schema version of the metadata.
+	Version int
+	Cells   []Cell
 }
 
 type Cell struct {
@@ -31,8 +40,8 @@ type Block struct {
 }
 
 type Action struct {
-	Name  string `json:"name"`
-	Descr string `json:"description"`
+	Name  string `json:"name" yaml:"name"`
+	Descr string `json:"description" yaml:"description"`
 }
 
 func (a Action) Title() string       { return a.Name }
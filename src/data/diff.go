@@ -0,0 +1,34 @@
+package data
+
+// Diff returns the titles of the targets added to and removed from the old
+// metadata, in the order of the respective metadata.
+func Diff(old, new *Root) (added, removed []string) {
+	return missingFrom(old, new), missingFrom(new, old)
+}
+
+// missingFrom returns the titles of the targets of r missing from other.
+func missingFrom(other, r *Root) []string {
+	has := map[string]bool{}
+	for _, t := range other.targetTitles() {
+		has[t] = true
+	}
+	var missing []string
+	for _, t := range r.targetTitles() {
+		if !has[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+func (r *Root) targetTitles() []string {
+	var titles []string
+	for ci, c := range r.Cells {
+		for bi, b := range c.Blocks {
+			for ti := range b.Targets {
+				titles = append(titles, r.TargetTitle(ci, bi, ti))
+			}
+		}
+	}
+	return titles
+}
@@ -0,0 +1,23 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	root := func(targets ...string) *Root {
+		b := Block{Name: "apps"}
+		for _, name := range targets {
+			b.Targets = append(b.Targets, Target{Name: name})
+		}
+		return &Root{Cells: []Cell{{Name: "backend", Blocks: []Block{b}}}}
+	}
+	added, removed := Diff(root("api", "lib", "worker"), root("worker", "web", "api"))
+	if want := []string{"//backend/apps/web"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added: got %v, want %v", added, want)
+	}
+	if want := []string{"//backend/apps/lib"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed: got %v, want %v", removed, want)
+	}
+}
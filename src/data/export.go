@@ -0,0 +1,113 @@
+package data
+
+import "fmt"
+
+// CellExport is the machine readable representation of a Cell.
+type CellExport struct {
+	Name      string        `json:"cell" yaml:"cell"`
+	HasReadme bool          `json:"hasReadme" yaml:"hasReadme"`
+	Blocks    []BlockExport `json:"cellBlocks" yaml:"cellBlocks"`
+}
+
+// BlockExport is the machine readable representation of a Block.
+type BlockExport struct {
+	Name      string         `json:"cellBlock" yaml:"cellBlock"`
+	Blocktype string         `json:"blockType" yaml:"blockType"`
+	HasReadme bool           `json:"hasReadme" yaml:"hasReadme"`
+	Targets   []TargetExport `json:"targets" yaml:"targets"`
+}
+
+// TargetExport is the machine readable representation of a Target.
+type TargetExport struct {
+	Name      string   `json:"name" yaml:"name"`
+	Descr     *string  `json:"description" yaml:"description"`
+	HasReadme bool     `json:"hasReadme" yaml:"hasReadme"`
+	Deps      []string `json:"deps" yaml:"deps"`
+	Actions   []Action `json:"actions" yaml:"actions"`
+}
+
+// ActionRecord is a flat, self-contained representation of a single action,
+// used where one record per action is emitted (e.g. newline-delimited json).
+type ActionRecord struct {
+	Spec        string   `json:"spec" yaml:"spec"`
+	Cell        string   `json:"cell" yaml:"cell"`
+	Block       string   `json:"cellBlock" yaml:"cellBlock"`
+	Blocktype   string   `json:"blockType" yaml:"blockType"`
+	Target      string   `json:"target" yaml:"target"`
+	Action      string   `json:"action" yaml:"action"`
+	Descr       string   `json:"description" yaml:"description"`
+	TargetDescr *string  `json:"targetDescription" yaml:"targetDescription"`
+	Deps        []string `json:"deps" yaml:"deps"`
+}
+
+// Export returns the full tree in its machine readable representation.
+// Readme contents are omitted in favour of presence flags.
+func (r *Root) Export() []CellExport {
+	cells := make([]CellExport, 0, len(r.Cells))
+	for _, c := range r.Cells {
+		blocks := make([]BlockExport, 0, len(c.Blocks))
+		for _, b := range c.Blocks {
+			targets := make([]TargetExport, 0, len(b.Targets))
+			for _, t := range b.Targets {
+				targets = append(targets, TargetExport{
+					Name:      t.Name,
+					Descr:     t.Descr,
+					HasReadme: t.Readme != nil,
+					Deps:      nonNil(t.Deps),
+					Actions:   nonNilActions(t.Actions),
+				})
+			}
+			blocks = append(blocks, BlockExport{
+				Name:      b.Name,
+				Blocktype: b.Blocktype,
+				HasReadme: b.Readme != nil,
+				Targets:   targets,
+			})
+		}
+		cells = append(cells, CellExport{
+			Name:      c.Name,
+			HasReadme: c.Readme != nil,
+			Blocks:    blocks,
+		})
+	}
+	return cells
+}
+
+// ActionRecords returns one flat record per action in the tree.
+func (r *Root) ActionRecords() []ActionRecord {
+	var records []ActionRecord
+	for _, c := range r.Cells {
+		for _, b := range c.Blocks {
+			for _, t := range b.Targets {
+				for _, a := range t.Actions {
+					records = append(records, ActionRecord{
+						Spec:        fmt.Sprintf(actionTemplate, c.Name, b.Name, t.Name, a.Name),
+						Cell:        c.Name,
+						Block:       b.Name,
+						Blocktype:   b.Blocktype,
+						Target:      t.Name,
+						Action:      a.Name,
+						Descr:       a.Descr,
+						TargetDescr: t.Descr,
+						Deps:        nonNil(t.Deps),
+					})
+				}
+			}
+		}
+	}
+	return records
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func nonNilActions(a []Action) []Action {
+	if a == nil {
+		return []Action{}
+	}
+	return a
+}
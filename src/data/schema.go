@@ -0,0 +1,235 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion is the latest version of the metadata schema. The metadata
+// is either a bare array of cells, which is version 1, or an envelope:
+//
+//	{ "version": 1, "cells": [ ... ] }
+const SchemaVersion = 1
+
+// Violation is a part of the metadata that doesn't conform to the schema.
+type Violation struct {
+	// Path is the JSON path of the offending value, e.g. '$[0].cellBlocks[1].cellBlock'.
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string { return v.Path + ": " + v.Message }
+
+// Parse decodes the metadata leniently: it accepts the bare array as well as
+// the envelope and ignores unknown fields. Use Validate to find violations of
+// the schema.
+func Parse(b []byte) (*Root, error) {
+	root := &Root{Version: 1}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		return root, json.Unmarshal(b, &root.Cells)
+	}
+	var env struct {
+		Version *int    `json:"version"`
+		Cells   *[]Cell `json:"cells"`
+	}
+	env.Cells = &root.Cells
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != nil {
+		root.Version = *env.Version
+	}
+	return root, nil
+}
+
+// CellNames decodes the names of the cells from the metadata.
+func CellNames(b []byte) ([]string, error) {
+	root, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range root.Cells {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// Validate returns the schema version of the metadata and all of its
+// violations of the schema. It fails on malformed JSON and on unsupported
+// schema versions.
+func Validate(b []byte) (int, []Violation, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return 0, nil, err
+	}
+	v := &validator{}
+	switch doc := doc.(type) {
+	case []interface{}:
+		v.cells("$", doc)
+		return 1, v.violations, nil
+	case map[string]interface{}:
+		if _, ok := doc["version"]; !ok {
+			v.add("$.version", "missing")
+			return 0, v.violations, nil
+		}
+		version, ok := doc["version"].(json.Number)
+		if !ok {
+			v.add("$.version", "must be the schema version, got %s", typeOf(doc["version"]))
+			return 0, v.violations, nil
+		}
+		n, err := version.Int64()
+		if err != nil || n < 1 {
+			v.add("$.version", "must be a positive integer, got %s", version)
+			return 0, v.violations, nil
+		}
+		if n > SchemaVersion {
+			return 0, nil, fmt.Errorf("metadata schema version %d is newer than the supported version %d, please update", n, SchemaVersion)
+		}
+		if cells, ok := v.array("$", doc, "cells", true); ok {
+			v.cells("$.cells", cells)
+		}
+		return int(n), v.violations, nil
+	}
+	v.add("$", "must be an array of cells or an object with 'version' and 'cells', got %s", typeOf(doc))
+	return 0, v.violations, nil
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{path, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) cells(path string, cells []interface{}) {
+	v.each(path, cells, "cell", "cell", func(path string, cell map[string]interface{}) {
+		v.optionalString(path, cell, "readme")
+		if blocks, ok := v.array(path, cell, "cellBlocks", true); ok {
+			v.blocks(path+".cellBlocks", blocks)
+		}
+	})
+}
+
+func (v *validator) blocks(path string, blocks []interface{}) {
+	v.each(path, blocks, "cell block", "cellBlock", func(path string, block map[string]interface{}) {
+		v.optionalString(path, block, "readme")
+		v.name(path, block, "blockType")
+		if targets, ok := v.array(path, block, "targets", true); ok {
+			v.targets(path+".targets", targets)
+		}
+	})
+}
+
+func (v *validator) targets(path string, targets []interface{}) {
+	v.each(path, targets, "target", "name", func(path string, target map[string]interface{}) {
+		v.optionalString(path, target, "readme")
+		v.optionalString(path, target, "description")
+		if deps, ok := v.array(path, target, "deps", false); ok {
+			for i, dep := range deps {
+				if s, ok := dep.(string); !ok {
+					v.add(fmt.Sprintf("%s.deps[%d]", path, i), "must be a target spec, got %s", typeOf(dep))
+				} else if s == "" {
+					v.add(fmt.Sprintf("%s.deps[%d]", path, i), "must not be empty")
+				}
+			}
+		}
+		if actions, ok := v.array(path, target, "actions", true); ok {
+			v.actions(path+".actions", actions)
+		}
+	})
+}
+
+func (v *validator) actions(path string, actions []interface{}) {
+	v.each(path, actions, "action", "name", func(path string, action map[string]interface{}) {
+		v.optionalString(path, action, "description")
+	})
+}
+
+// each validates the objects of the array, which must have unique, non-empty
+// names under the given key.
+func (v *validator) each(path string, items []interface{}, kind, key string, validate func(string, map[string]interface{})) {
+	seen := map[string]string{}
+	for i, item := range items {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			v.add(p, "must be a %s object, got %s", kind, typeOf(item))
+			continue
+		}
+		if name, ok := v.name(p, obj, key); ok {
+			if first, dup := seen[name]; dup {
+				v.add(p+"."+key, "duplicate %s name '%s', first at %s", kind, name, first)
+			} else {
+				seen[name] = p
+			}
+		}
+		validate(p, obj)
+	}
+}
+
+// name validates a required, non-empty string.
+func (v *validator) name(path string, obj map[string]interface{}, key string) (string, bool) {
+	val, ok := obj[key]
+	if !ok {
+		v.add(path+"."+key, "missing")
+		return "", false
+	}
+	s, ok := val.(string)
+	if !ok {
+		v.add(path+"."+key, "must be a string, got %s", typeOf(val))
+		return "", false
+	}
+	if strings.TrimSpace(s) == "" {
+		v.add(path+"."+key, "must not be empty")
+		return "", false
+	}
+	return s, true
+}
+
+func (v *validator) optionalString(path string, obj map[string]interface{}, key string) {
+	if val, ok := obj[key]; ok && val != nil {
+		if _, ok := val.(string); !ok {
+			v.add(path+"."+key, "must be a string or null, got %s", typeOf(val))
+		}
+	}
+}
+
+// array validates an array, which may be empty and, unless required, missing.
+func (v *validator) array(path string, obj map[string]interface{}, key string, required bool) ([]interface{}, bool) {
+	val, ok := obj[key]
+	if !ok {
+		if required {
+			v.add(path+"."+key, "missing")
+		}
+		return nil, false
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		v.add(path+"."+key, "must be an array, got %s", typeOf(val))
+	}
+	return arr, ok
+}
+
+func typeOf(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", val)
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+const cells = `[{"cell": "backend", "cellBlocks": [{"cellBlock": "apps", "blockType": "installables", "targets": [
+	{"name": "api", "deps": ["//backend/apps/lib"], "description": "the api", "actions": [{"name": "build", "description": "build it"}]},
+	{"name": "lib", "deps": [], "actions": []}
+]}]}]`
+
+func TestParse(t *testing.T) {
+	for _, doc := range []string{cells, `{"version": 1, "cells": ` + cells + `}`} {
+		root, err := Parse([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root.Version != 1 || root.Len() != 2 || root.TargetTitle(0, 0, 0) != "//backend/apps/api" {
+			t.Errorf("got %+v", root)
+		}
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	root, err := Parse([]byte(`{"cells": [{"cell": "backend", "extra": true, "cellBlocks": [{"cellBlock": "apps", "targets": [{"name": "api"}]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Version != 1 || root.Len() != 1 {
+		t.Errorf("got %+v", root)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	_, violations, err := Validate([]byte(`[
+		{"cell": "backend", "cellBlocks": [
+			{"blockType": "installables", "targets": []},
+			{"cellBlock": "apps", "blockType": "installables", "targets": [
+				{"name": "api", "deps": [1], "actions": [{"name": ""}]},
+				{"name": "api", "description": 2, "actions": null}
+			]}
+		]},
+		{"cell": "backend", "cellBlocks": []}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Violation{
+		{"$[0].cellBlocks[0].cellBlock", "missing"},
+		{"$[0].cellBlocks[1].targets[0].deps[0]", "must be a target spec, got a number"},
+		{"$[0].cellBlocks[1].targets[0].actions[0].name", "must not be empty"},
+		{"$[0].cellBlocks[1].targets[1].name", "duplicate target name 'api', first at $[0].cellBlocks[1].targets[0]"},
+		{"$[0].cellBlocks[1].targets[1].description", "must be a string or null, got a number"},
+		{"$[0].cellBlocks[1].targets[1].actions", "must be an array, got null"},
+		{"$[1].cell", "duplicate cell name 'backend', first at $[0]"},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", violations, want)
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	if _, _, err := Validate([]byte(`{"version": 2, "cells": []}`)); err == nil {
+		t.Error("got no error for a newer schema version")
+	}
+	_, violations, err := Validate([]byte(`{"cells": []}`))
+	if err != nil || len(violations) != 1 || violations[0].Path != "$.version" {
+		t.Errorf("got %v, %v, want a missing version", violations, err)
+	}
+}
@@ -3,6 +3,7 @@ package env
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	spec "github.com/numtide/prj-spec/contrib/go"
@@ -19,6 +20,9 @@ var extraNixConfig = strings.Join([]string{
 	// write-lock-file = false,
 }, "\n")
 
+// SetEnv sets the PRJ_* variables and extends NIX_CONFIG for the nix
+// invocations and actions. Call it once at startup: every call extends
+// NIX_CONFIG again.
 func SetEnv() {
 	spec.SetAll() // PRJ_*
 
@@ -42,3 +46,12 @@ func GetProjectMetadataCacheDir() (string, error) {
 	}
 	return path, nil
 }
+
+// GetStateRunPath returns the out-link for an action run as a child process,
+// while it runs; 'last-action' takes over its store path afterwards. The name
+// must be unique to the run, for concurrent runs.
+func GetStateRunPath(name string) (string, error) {
+	return spec.StateFile(filepath.Join("runs", name))
+}
+
+func GetStateHistoryPath() (string, error) { return spec.StateFile("history.jsonl") }
@@ -0,0 +1,248 @@
+// Package filter implements the selection of targets and actions shared by
+// the CLI, the TUI and the shell completion, so that all of them agree on
+// what a given pattern matches.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+// Spec is a glob pattern in the form of '//cell/block/target:action'.
+// Omitted trailing parts match anything, so '//backend' selects every
+// target of the 'backend' cell.
+type Spec struct {
+	Cell   string
+	Block  string
+	Target string
+	Action string
+}
+
+// Filter selects targets and actions of a data.Root.
+// The zero value matches everything.
+type Filter struct {
+	// Specs are alternative spec patterns, of which at least one must match.
+	Specs []Spec
+	// BlockType is a glob pattern for the block type.
+	BlockType string
+	// Action is a glob pattern for the action name.
+	Action string
+	// HasReadme, if set, requires the target to have (or not have) a readme.
+	HasReadme *bool
+	// Terms are matched fuzzily, see ParseQuery.
+	Terms []Term
+}
+
+// ParseSpec parses a spec pattern and validates its globs. Like in a query,
+// patterns start with '//'.
+func ParseSpec(pattern string) (Spec, error) {
+	var s Spec
+	if !strings.HasPrefix(pattern, "//") {
+		return Spec{}, fmt.Errorf("invalid pattern '%s': must start with '//', e.g. '//%s'", pattern, pattern)
+	}
+	rest := strings.TrimPrefix(pattern, "//")
+	parts := strings.SplitN(rest, "/", 3)
+	switch len(parts) {
+	case 3:
+		s.Target = parts[2]
+		fallthrough
+	case 2:
+		s.Block = parts[1]
+		fallthrough
+	case 1:
+		s.Cell = parts[0]
+	}
+	// the action is always the last part, even if no target is given
+	last := &s.Cell
+	if s.Target != "" {
+		last = &s.Target
+	} else if s.Block != "" {
+		last = &s.Block
+	}
+	if i := strings.LastIndex(*last, ":"); i >= 0 {
+		s.Action = (*last)[i+1:]
+		*last = (*last)[:i]
+	}
+	for _, p := range []string{s.Cell, s.Block, s.Target, s.Action} {
+		if _, err := path.Match(p, ""); err != nil {
+			return Spec{}, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+	return s, nil
+}
+
+// New returns a Filter for the given spec patterns.
+func New(patterns ...string) (*Filter, error) {
+	f := &Filter{}
+	for _, p := range patterns {
+		s, err := ParseSpec(p)
+		if err != nil {
+			return nil, err
+		}
+		f.Specs = append(f.Specs, s)
+	}
+	return f, nil
+}
+
+// Validate reports malformed glob patterns in the non-spec fields.
+func (f *Filter) Validate() error {
+	for _, p := range []string{f.BlockType, f.Action} {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	return nil
+}
+
+// glob matches name against pattern; an empty pattern matches anything.
+func glob(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func (s Spec) matchTarget(c data.Cell, b data.Block, t data.Target) bool {
+	return glob(s.Cell, c.Name) && glob(s.Block, b.Name) && glob(s.Target, t.Name)
+}
+
+func (f *Filter) matchTargetFields(b data.Block, t data.Target) bool {
+	if f.HasReadme != nil && *f.HasReadme != (t.Readme != nil) {
+		return false
+	}
+	return glob(f.BlockType, b.Blocktype)
+}
+
+// MatchAction reports whether the action of the given target is selected.
+func (f *Filter) MatchAction(c data.Cell, b data.Block, t data.Target, a data.Action) bool {
+	if f == nil {
+		return true
+	}
+	return f.matchTargetTerms(c, b, t) && f.matchAction(c, b, t, a)
+}
+
+// matchTargetTerms reports whether the target's fields and all terms but
+// those qualified with 'action:' match the target.
+func (f *Filter) matchTargetTerms(c data.Cell, b data.Block, t data.Target) bool {
+	if !f.matchTargetFields(b, t) {
+		return false
+	}
+	for _, term := range f.Terms {
+		if term.Field == FieldAction {
+			continue
+		}
+		if _, _, ok := term.matchTarget(c, b, t); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAction reports whether the action matches the action pattern, the
+// terms qualified with 'action:' and any of the specs, leaving the target's
+// fields and the other terms aside.
+func (f *Filter) matchAction(c data.Cell, b data.Block, t data.Target, a data.Action) bool {
+	if !glob(f.Action, a.Name) {
+		return false
+	}
+	for _, term := range f.Terms {
+		if term.Field != FieldAction {
+			continue
+		}
+		if _, ok := fuzzyMatch(term.Text, a.Name); !ok {
+			return false
+		}
+	}
+	if len(f.Specs) == 0 {
+		return true
+	}
+	for _, s := range f.Specs {
+		if s.matchTarget(c, b, t) && glob(s.Action, a.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectsActions reports whether the filter selects actions rather than
+// targets only, i.e. if it has an action pattern, a term qualified with
+// 'action:' or a spec with an action.
+func (f *Filter) selectsActions() bool {
+	if f.Action != "" {
+		return true
+	}
+	for _, term := range f.Terms {
+		if term.Field == FieldAction {
+			return true
+		}
+	}
+	for _, s := range f.Specs {
+		if s.Action != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSpecs reports whether the target matches any of the specs, if any.
+func (f *Filter) matchSpecs(c data.Cell, b data.Block, t data.Target) bool {
+	if len(f.Specs) == 0 {
+		return true
+	}
+	for _, s := range f.Specs {
+		if s.matchTarget(c, b, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTarget reports whether the target is selected: if the filter selects
+// actions, that's the case if any of its actions is selected.
+func (f *Filter) MatchTarget(c data.Cell, b data.Block, t data.Target) bool {
+	_, ok := f.Match(c, b, t)
+	return ok
+}
+
+// Apply returns a copy of the root pruned down to the selected actions.
+// Targets, blocks and cells left without any selected action are dropped.
+func (f *Filter) Apply(r *data.Root) *data.Root {
+	if f == nil {
+		return r
+	}
+	pruned := &data.Root{}
+	for _, c := range r.Cells {
+		cell := c
+		cell.Blocks = nil
+		for _, b := range c.Blocks {
+			block := b
+			block.Targets = nil
+			for _, t := range b.Targets {
+				if !f.matchTargetTerms(c, b, t) {
+					continue
+				}
+				target := t
+				target.Actions = nil
+				for _, a := range t.Actions {
+					if f.matchAction(c, b, t, a) {
+						target.Actions = append(target.Actions, a)
+					}
+				}
+				if len(target.Actions) > 0 {
+					block.Targets = append(block.Targets, target)
+				}
+			}
+			if len(block.Targets) > 0 {
+				cell.Blocks = append(cell.Blocks, block)
+			}
+		}
+		if len(cell.Blocks) > 0 {
+			pruned.Cells = append(pruned.Cells, cell)
+		}
+	}
+	return pruned
+}
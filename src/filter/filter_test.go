@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+func strPtr(s string) *string { return &s }
+func boolPtr(b bool) *bool    { return &b }
+
+func testRoot() *data.Root {
+	return &data.Root{Cells: []data.Cell{
+		{Name: "backend", Blocks: []data.Block{
+			{Name: "apps", Blocktype: "installables", Targets: []data.Target{
+				{Name: "api-server", Readme: strPtr("# api"), Actions: []data.Action{{Name: "build"}, {Name: "run"}}},
+				{Name: "worker", Actions: []data.Action{{Name: "build"}}},
+			}},
+			{Name: "oci", Blocktype: "containers", Targets: []data.Target{
+				{Name: "api-image", Actions: []data.Action{{Name: "build"}, {Name: "publish"}}},
+			}},
+		}},
+		{Name: "frontend", Blocks: []data.Block{
+			{Name: "apps", Blocktype: "installables", Targets: []data.Target{
+				{Name: "web", Actions: []data.Action{{Name: "build"}, {Name: "run"}}},
+			}},
+		}},
+	}}
+}
+
+func specs(r *data.Root) []string {
+	var s []string
+	for _, rec := range r.ActionRecords() {
+		s = append(s, rec.Spec)
+	}
+	return s
+}
+
+func TestParseSpec(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Spec
+	}{
+		{"//backend", Spec{Cell: "backend"}},
+		{"//backend/*/api*", Spec{Cell: "backend", Block: "*", Target: "api*"}},
+		{"//*/*/*:build", Spec{Cell: "*", Block: "*", Target: "*", Action: "build"}},
+		{"//backend/apps:run", Spec{Cell: "backend", Block: "apps", Action: "run"}},
+		{"//", Spec{}},
+	} {
+		got, err := ParseSpec(tc.in)
+		if err != nil {
+			t.Fatalf("ParseSpec(%q): %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseSpec(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+	if _, err := ParseSpec("//back[end"); err == nil {
+		t.Errorf("ParseSpec with malformed glob succeeded, want failure")
+	}
+	if _, err := ParseSpec("backend/*"); err == nil {
+		t.Errorf("ParseSpec without leading '//' succeeded, want failure")
+	}
+}
+
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		patterns []string
+		modify   func(f *Filter)
+		want     []string
+	}{
+		{"glob", []string{"//backend/*/api*"}, nil, []string{
+			"//backend/apps/api-server:build", "//backend/apps/api-server:run",
+			"//backend/oci/api-image:build", "//backend/oci/api-image:publish",
+		}},
+		{"alternatives", []string{"//frontend", "//backend/oci/*:publish"}, nil, []string{
+			"//backend/oci/api-image:publish", "//frontend/apps/web:build", "//frontend/apps/web:run",
+		}},
+		{"block type", nil, func(f *Filter) { f.BlockType = "containers" }, []string{
+			"//backend/oci/api-image:build", "//backend/oci/api-image:publish",
+		}},
+		{"action", []string{"//backend"}, func(f *Filter) { f.Action = "run" }, []string{
+			"//backend/apps/api-server:run",
+		}},
+		{"has readme", nil, func(f *Filter) { f.HasReadme = boolPtr(true) }, []string{
+			"//backend/apps/api-server:build", "//backend/apps/api-server:run",
+		}},
+	} {
+		f, err := New(tc.patterns...)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.modify != nil {
+			tc.modify(f)
+		}
+		got := specs(f.Apply(testRoot()))
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
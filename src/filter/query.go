@@ -0,0 +1,178 @@
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+// The fields of a target that a Term can be qualified with.
+const (
+	FieldTitle  = "title"
+	FieldDescr  = "descr"
+	FieldType   = "type"
+	FieldAction = "action"
+)
+
+// Fields are the qualifiers of query terms, like 'type:' in 'type:containers'.
+var Fields = []string{FieldTitle, FieldDescr, FieldType, FieldAction}
+
+// Term is a word of a query, matched fuzzily against a field of a target,
+// or against any of them if Field is empty.
+type Term struct {
+	Field string
+	Text  string
+}
+
+// Match is how well a target matches the terms of a filter.
+type Match struct {
+	// Score ranks the matches, higher is better.
+	Score int
+	// TitleIndexes are the positions in the target's title that matched.
+	TitleIndexes []int
+}
+
+// ParseQuery parses a query like 'type:containers action:publish api' into
+// a filter: words starting with '//' are spec patterns, the others Terms,
+// all of which must match. Words with an unknown qualifier, e.g. 'api:run',
+// are matched as a whole.
+func ParseQuery(query string) (*Filter, error) {
+	f := &Filter{}
+	for _, word := range strings.Fields(query) {
+		if strings.HasPrefix(word, "//") {
+			s, err := ParseSpec(word)
+			if err != nil {
+				return nil, err
+			}
+			f.Specs = append(f.Specs, s)
+			continue
+		}
+		t := Term{Text: word}
+		if field, text, ok := strings.Cut(word, ":"); ok && isField(field) {
+			t = Term{field, text}
+		}
+		// a bare qualifier, e.g. while it's being typed, matches anything
+		if t.Text != "" {
+			f.Terms = append(f.Terms, t)
+		}
+	}
+	return f, nil
+}
+
+func isField(name string) bool {
+	for _, f := range Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatch matches pattern against s like the TUI's filter does.
+func fuzzyMatch(pattern, s string) (fuzzy.Match, bool) {
+	matches := fuzzy.Find(pattern, []string{s})
+	if len(matches) == 0 {
+		return fuzzy.Match{}, false
+	}
+	return matches[0], true
+}
+
+// bestMatch returns the best of the fuzzy matches of pattern against names.
+func bestMatch(pattern string, names []string) (fuzzy.Match, bool) {
+	var (
+		best  fuzzy.Match
+		found bool
+	)
+	for _, name := range names {
+		if m, ok := fuzzyMatch(pattern, name); ok && (!found || m.Score > best.Score) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// matchTarget matches the term against the target, returning the positions
+// in its title that matched, if any.
+func (t Term) matchTarget(c data.Cell, b data.Block, target data.Target) (score int, title []int, ok bool) {
+	var descr string
+	if target.Descr != nil {
+		descr = *target.Descr
+	}
+	var actions []string
+	for _, a := range target.Actions {
+		actions = append(actions, a.Name)
+	}
+	var candidates []string
+	switch t.Field {
+	case FieldTitle:
+		candidates = []string{titleOf(c, b, target)}
+	case FieldDescr:
+		candidates = []string{descr}
+	case FieldType:
+		candidates = []string{b.Blocktype}
+	case FieldAction:
+		candidates = actions
+	default:
+		candidates = append([]string{titleOf(c, b, target), descr, b.Blocktype}, actions...)
+	}
+	m, ok := bestMatch(t.Text, candidates)
+	if !ok {
+		return 0, nil, false
+	}
+	// highlight the title even if another field matched better
+	if t.Field == "" || t.Field == FieldTitle {
+		if tm, ok := fuzzyMatch(t.Text, titleOf(c, b, target)); ok {
+			title = tm.MatchedIndexes
+		}
+	}
+	return m.Score, title, true
+}
+
+// titleOf renders the target's title like data.Root.TargetTitle.
+func titleOf(c data.Cell, b data.Block, t data.Target) string {
+	return fmt.Sprintf("//%s/%s/%s", c.Name, b.Name, t.Name)
+}
+
+// Match reports whether the target is selected and, if so, how well it
+// matches the filter's Terms. Each term is matched against the target only
+// once; if the filter selects actions, the target must have one that's
+// selected, too.
+func (f *Filter) Match(c data.Cell, b data.Block, t data.Target) (Match, bool) {
+	if f == nil {
+		return Match{}, true
+	}
+	if !f.matchTargetFields(b, t) {
+		return Match{}, false
+	}
+	var (
+		m       Match
+		matched = map[int]bool{}
+	)
+	for _, term := range f.Terms {
+		score, title, ok := term.matchTarget(c, b, t)
+		if !ok {
+			return Match{}, false
+		}
+		m.Score += score
+		for _, i := range title {
+			if !matched[i] {
+				matched[i] = true
+				m.TitleIndexes = append(m.TitleIndexes, i)
+			}
+		}
+	}
+	sort.Ints(m.TitleIndexes)
+	if !f.selectsActions() {
+		return m, f.matchSpecs(c, b, t)
+	}
+	for _, a := range t.Actions {
+		if f.matchAction(c, b, t, a) {
+			return m, true
+		}
+	}
+	return Match{}, false
+}
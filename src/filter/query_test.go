@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+func TestParseQuery(t *testing.T) {
+	f, err := ParseQuery("type:containers //backend api action: foo:bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSpecs := []Spec{{Cell: "backend"}}
+	wantTerms := []Term{{FieldType, "containers"}, {"", "api"}, {"", "foo:bar"}}
+	if !reflect.DeepEqual(f.Specs, wantSpecs) || !reflect.DeepEqual(f.Terms, wantTerms) {
+		t.Errorf("got %+v %+v, want %+v %+v", f.Specs, f.Terms, wantSpecs, wantTerms)
+	}
+	if _, err := ParseQuery("//back[end api"); err == nil {
+		t.Errorf("ParseQuery with malformed glob succeeded, want failure")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"type:containers action:pub", []string{"//backend/oci/api-image:publish"}},
+		{"wrk", []string{"//backend/apps/worker:build"}},
+		// matches the action names of the web target, but selects them all
+		{"//frontend run", []string{"//frontend/apps/web:build", "//frontend/apps/web:run"}},
+		{"descr:images", []string{"//backend/oci/api-image:build", "//backend/oci/api-image:publish"}},
+		{"action:zzz", nil},
+	} {
+		f, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		root := testRoot()
+		root.Cells[0].Blocks[1].Targets[0].Descr = strPtr("Container images")
+		if got := specs(f.Apply(root)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestMatchHighlightsTitle(t *testing.T) {
+	f, err := ParseQuery("type:containers img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := testRoot()
+	c := root.Cells[0]
+	b := c.Blocks[1]
+	m, ok := f.Match(c, b, b.Targets[0])
+	if !ok {
+		t.Fatal("no match")
+	}
+	// '//backend/oci/api-image'
+	if want := []int{18, 19, 21}; !reflect.DeepEqual(m.TitleIndexes, want) {
+		t.Errorf("got %v, want %v", m.TitleIndexes, want)
+	}
+	if _, ok := f.Match(c, c.Blocks[0], c.Blocks[0].Targets[0]); ok {
+		t.Errorf("matched a target of another block type")
+	}
+}
+
+func TestMatchTargetWithoutActions(t *testing.T) {
+	c := data.Cell{Name: "backend"}
+	b := data.Block{Name: "apps", Blocktype: "installables"}
+	target := data.Target{Name: "lib"}
+	for query, want := range map[string]bool{
+		"":                  true,
+		"lib":               true,
+		"type:installables": true,
+		"//backend/apps":    true,
+		"action:build":      false,
+		"//backend/*/*:run": false,
+	} {
+		f, err := ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := f.Match(c, b, target); ok != want {
+			t.Errorf("%q: got %v, want %v", query, ok, want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package flake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paisano-nix/paisano/config"
+)
+
+// Spec renders the '//cell/block/target:action' spec of the command.
+func (c *RunActionCmd) Spec() string {
+	return fmt.Sprintf("//%s/%s/%s:%s", c.Cell, c.Block, c.Target, c.Action)
+}
+
+// BuildActions builds the derivations of all actions with a single nix
+// invocation and returns the store path of each action's executable, in order.
+// The build results are told apart by the actions' derivations, which are
+// evaluated beforehand, all at once.
+func BuildActions(cmds []RunActionCmd) ([]string, error) {
+	nix, err := getNix()
+	if err != nil {
+		return nil, err
+	}
+	currentSystem, err := getCurrentSystem()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		installables []string
+		attrPaths    [][]string
+		impure       bool
+	)
+	for _, c := range cmds {
+		args, err := c.getArgs(currentSystem)
+		if err != nil {
+			return nil, err
+		}
+		n := len(installables)
+		for _, a := range args {
+			if a == "--impure" {
+				impure = true
+			} else {
+				installables = append(installables, a)
+			}
+		}
+		if len(installables) != n+1 {
+			return nil, fmt.Errorf("%s doesn't render to a single installable: %v", c.Spec(), args)
+		}
+		attrPaths = append(attrPaths, c.attrPath(currentSystem))
+	}
+	flags := []string{
+		"--no-update-lock-file",
+		"--no-write-lock-file",
+		"--no-warn-dirty",
+		"--accept-flake-config",
+	}
+	if impure {
+		flags = append(flags, "--impure")
+	}
+	flags = append(flags, config.Get().NixFlags...)
+
+	drvs, err := evalDrvPaths(nix, flags, attrPaths)
+	if err != nil {
+		return nil, err
+	}
+	if len(drvs) != len(cmds) {
+		return nil, fmt.Errorf("evaluated %d derivations for %d actions", len(drvs), len(cmds))
+	}
+
+	args := append([]string{"build", "--json", "--no-link", "--builders-use-substitutes"}, flags...)
+	args = append(args, installables...)
+	stdout := new(bytes.Buffer)
+	cmd := exec.Command(nix, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("while building actions: %w", err)
+	}
+	var outs []outt
+	if err := json.Unmarshal(stdout.Bytes(), &outs); err != nil {
+		return nil, fmt.Errorf("while decoding build results: %w", err)
+	}
+	byDrv := map[string]string{}
+	for _, o := range outs {
+		byDrv[o.DrvPath] = o.Outputs["out"]
+	}
+	paths := make([]string, len(cmds))
+	for i, drv := range drvs {
+		if paths[i] = byDrv[drv]; paths[i] == "" {
+			return nil, fmt.Errorf("build of %s (%s) has no 'out' output", cmds[i].Spec(), drv)
+		}
+	}
+	return paths, nil
+}
+
+// evalDrvPaths evaluates the derivations of the actions at attrPaths, below
+// the flake's actions, in a single evaluation.
+func evalDrvPaths(nix string, flags []string, attrPaths [][]string) ([]string, error) {
+	paths, err := json.Marshal(attrPaths)
+	if err != nil {
+		return nil, err
+	}
+	apply := fmt.Sprintf("actions: map (p: (builtins.foldl' (s: n: s.${n}) actions p).drvPath) (builtins.fromJSON %s)", nixString(string(paths)))
+	args := append([]string{"eval", "--json"}, flags...)
+	args = append(args, flakeRegistry(".")+".actions", "--apply", apply)
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command(nix, args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("while evaluating the actions' derivations: %w", AsEvalError(err, stderr.Bytes()))
+	}
+	var drvs []string
+	if err := json.Unmarshal(out, &drvs); err != nil {
+		return nil, fmt.Errorf("while decoding the actions' derivations: %w", err)
+	}
+	return drvs, nil
+}
+
+// nixString renders s as a nix string literal.
+func nixString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`).Replace(s) + `"`
+}
+
+// BatchResult is the outcome of one action of a Batch.
+type BatchResult struct {
+	Cmd      RunActionCmd
+	ExitCode int
+	Duration time.Duration
+	// Skipped is set if the action didn't run, because an earlier one failed.
+	Skipped bool
+	Err     error
+}
+
+// Failed reports whether the action ran, but not successfully.
+func (r BatchResult) Failed() bool { return r.Err != nil || r.ExitCode != 0 }
+
+// Batch runs several actions off a single build.
+type Batch struct {
+	Cmds []RunActionCmd
+	// Parallel is the number of actions executed at the same time;
+	// anything below 2 executes them sequentially.
+	Parallel int
+	// FailFast skips all actions that haven't been started yet once one failed.
+	FailFast bool
+	// Stdout and Stderr receive the output of all actions, each line
+	// prefixed with the action's spec.
+	Stdout io.Writer
+	Stderr io.Writer
+	// KillGrace overrides DefaultKillGrace for actions that are
+	// interrupted because the context is done.
+	KillGrace time.Duration
+}
+
+// Run builds all actions and then executes them, forwarding extraArgs to each.
+// The returned error is only set if the batch couldn't be run at all;
+// failures of individual actions are reported in the results.
+func (b *Batch) Run(ctx context.Context, extraArgs []string) ([]BatchResult, error) {
+	paths, err := BuildActions(b.Cmds)
+	if err != nil {
+		return nil, err
+	}
+	return b.exec(ctx, paths, extraArgs), nil
+}
+
+// exec executes the built actions, the executable of each at paths.
+func (b *Batch) exec(ctx context.Context, paths []string, extraArgs []string) []BatchResult {
+	var (
+		results  = make([]BatchResult, len(b.Cmds))
+		parallel = b.Parallel
+		sem      chan struct{}
+		wg       sync.WaitGroup
+		outMu    sync.Mutex
+		failed   int32
+	)
+	if parallel < 1 {
+		parallel = 1
+	}
+	sem = make(chan struct{}, parallel)
+
+	for i := range b.Cmds {
+		sem <- struct{}{}
+		results[i].Cmd = b.Cmds[i]
+		if ctx.Err() != nil || atomic.LoadInt32(&failed) > 0 {
+			results[i].Skipped = true
+			<-sem
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			prefix := fmt.Sprintf("\x1b[1;35m[%s]\x1b[0m ", b.Cmds[i].Spec())
+			stdout := &prefixWriter{w: b.Stdout, mu: &outMu, prefix: prefix}
+			stderr := &prefixWriter{w: b.Stderr, mu: &outMu, prefix: prefix}
+
+			start := time.Now()
+			cmd := exec.Command(paths[i], extraArgs...)
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			// in a process group of its own, so that interrupting it
+			// reaches the processes it spawned, which share its output
+			err := runCmd(ctx, cmd, RunOptions{KillGrace: b.KillGrace, ProcessGroup: true})
+			stdout.Flush()
+			stderr.Flush()
+
+			results[i].Duration = time.Since(start)
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				results[i].ExitCode = exitErr.ExitCode()
+			} else if err != nil {
+				results[i].ExitCode = -1
+				results[i].Err = err
+			}
+			if b.FailFast && results[i].Failed() {
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// prefixWriter prefixes every line written to w and serializes whole
+// lines on mu, so that the output of concurrent actions doesn't interleave.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes out a trailing line that wasn't terminated by a newline.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.writeLine(append(p.buf, '\n'))
+	p.buf = nil
+	return err
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := bufio.NewWriter(p.w)
+	w.WriteString(p.prefix)
+	w.Write(line)
+	return w.Flush()
+}
@@ -0,0 +1,204 @@
+package flake
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"single line", []string{"hello\n"}, "[a] hello\n"},
+		{"several lines", []string{"one\ntwo\n"}, "[a] one\n[a] two\n"},
+		{"split line", []string{"hel", "lo\nwor", "ld\n"}, "[a] hello\n[a] world\n"},
+		{"unterminated", []string{"one\ntwo"}, "[a] one\n[a] two\n"},
+		{"empty lines", []string{"\n\n"}, "[a] \n[a] \n"},
+		{"nothing", nil, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &prefixWriter{w: &buf, mu: &sync.Mutex{}, prefix: "[a] "}
+			for _, s := range tc.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			w.Flush()
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrefixWriterInterleaving(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		mu   sync.Mutex
+		a    = &prefixWriter{w: &buf, mu: &mu, prefix: "[a] "}
+		b    = &prefixWriter{w: &buf, mu: &mu, prefix: "[b] "}
+		want = "[a] one\n[b] two\n[a] three\n"
+	)
+	a.Write([]byte("on"))
+	b.Write([]byte("tw"))
+	a.Write([]byte("e\nthr"))
+	b.Write([]byte("o\n"))
+	a.Write([]byte("ee\n"))
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// concurrent writers never split each other's lines
+	buf.Reset()
+	var wg sync.WaitGroup
+	for _, w := range []*prefixWriter{a, b} {
+		wg.Add(1)
+		go func(w *prefixWriter) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				w.Write([]byte("x"))
+				w.Write([]byte("y\n"))
+			}
+		}(w)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 200 {
+		t.Fatalf("got %d lines, want 200", len(lines))
+	}
+	for _, l := range lines {
+		if l != "[a] xy" && l != "[b] xy" {
+			t.Fatalf("interleaved line %q", l)
+		}
+	}
+}
+
+// script writes an executable shell script to dir.
+func script(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func batchCmds(names ...string) []RunActionCmd {
+	var cmds []RunActionCmd
+	for _, n := range names {
+		cmds = append(cmds, RunActionCmd{Cell: "cell", Block: "block", Target: n, Action: "run"})
+	}
+	return cmds
+}
+
+type outcome struct {
+	ExitCode int
+	Skipped  bool
+	Failed   bool
+}
+
+func outcomes(results []BatchResult) []outcome {
+	var o []outcome
+	for _, r := range results {
+		o = append(o, outcome{r.ExitCode, r.Skipped, r.Failed()})
+	}
+	return o
+}
+
+func TestBatchExec(t *testing.T) {
+	dir := t.TempDir()
+	ok := script(t, dir, "ok", `echo "ok $*"`)
+	fail := script(t, dir, "fail", `echo "failing" >&2; exit 3`)
+	missing := filepath.Join(dir, "missing")
+
+	for _, tc := range []struct {
+		name     string
+		paths    []string
+		parallel int
+		failFast bool
+		want     []outcome
+	}{
+		{"all succeed", []string{ok, ok}, 1, false, []outcome{{0, false, false}, {0, false, false}}},
+		{"exit codes", []string{ok, fail, ok}, 1, false, []outcome{{0, false, false}, {3, false, true}, {0, false, false}}},
+		{"fail fast", []string{ok, fail, ok, ok}, 1, true, []outcome{{0, false, false}, {3, false, true}, {0, true, false}, {0, true, false}}},
+		{"parallel", []string{fail, ok, ok}, 3, false, []outcome{{3, false, true}, {0, false, false}, {0, false, false}}},
+		{"missing executable", []string{missing}, 1, false, []outcome{{-1, false, true}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			b := &Batch{
+				Cmds:     batchCmds("a", "b", "c", "d")[:len(tc.paths)],
+				Parallel: tc.parallel,
+				FailFast: tc.failFast,
+				Stdout:   &stdout,
+				Stderr:   &stderr,
+			}
+			results := b.exec(context.Background(), tc.paths, []string{"x", "y"})
+			if got := outcomes(results); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+			for i, r := range results {
+				if r.Cmd != b.Cmds[i] {
+					t.Errorf("result %d is of %s, want %s", i, r.Cmd.Spec(), b.Cmds[i].Spec())
+				}
+			}
+			if tc.paths[0] == ok && !strings.Contains(stdout.String(), "[//cell/block/a:run]\x1b[0m ok x y\n") {
+				t.Errorf("stdout lacks the prefixed output of the first action: %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestBatchExecCancel(t *testing.T) {
+	dir := t.TempDir()
+	// ignores the interrupt, so that it has to be killed
+	stubborn := script(t, dir, "stubborn", `trap "" INT; while :; do sleep 0.1; done`)
+	sleeper := script(t, dir, "sleeper", `sleep 30`)
+
+	for _, tc := range []struct {
+		name string
+		path string
+	}{
+		{"interrupted", sleeper},
+		{"killed", stubborn},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(200*time.Millisecond, cancel)
+			b := &Batch{
+				Cmds:      batchCmds("a", "b"),
+				KillGrace: 300 * time.Millisecond,
+				Stdout:    &bytes.Buffer{},
+				Stderr:    &bytes.Buffer{},
+			}
+			start := time.Now()
+			results := b.exec(ctx, []string{tc.path, tc.path}, nil)
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("took %s to cancel", d)
+			}
+			if !results[0].Failed() || results[0].Skipped {
+				t.Errorf("first action: got %+v, want it to fail", results[0])
+			}
+			if !results[1].Skipped {
+				t.Errorf("second action: got %+v, want it to be skipped", results[1])
+			}
+		})
+	}
+}
+
+func TestNixString(t *testing.T) {
+	got := nixString(`[["a\"b","${c}","d\\e"]]`)
+	want := `"[[\"a\\\"b\",\"\${c}\",\"d\\\\e\"]]"`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package flake
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EvalError is a failed evaluation of the flake, as reported by nix.
+type EvalError struct {
+	// Message is the innermost error message.
+	Message string
+	// File, Line and Column locate the error, if nix reported a location.
+	// Files within the flake are relative to the flake's root.
+	File   string
+	Line   int
+	Column int
+	// AttrPath is the innermost attribute nix was evaluating, if known.
+	AttrPath string
+	// Snippet is the source code around the error, as printed by nix.
+	Snippet []string
+	// Trace holds the frames of the evaluation trace, outermost first.
+	Trace []string
+	// Stderr is the unparsed output of nix.
+	Stderr string
+}
+
+var (
+	ansiRe      = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	locationRe  = regexp.MustCompile(`(?m)(?:^|,? )at (\S+?):(\d+):(\d+):?$`)
+	snippetRe   = regexp.MustCompile(`^\s*\d*\|`)
+	attributeRe = regexp.MustCompile(`attribute '([^']+)'`)
+	storeRe     = regexp.MustCompile(`^/nix/store/[0-9a-z]{32}-source/`)
+)
+
+// ParseEvalError parses the stderr of a failed nix evaluation, with or
+// without '--show-trace', in the formats of nix 2.3 and later.
+func ParseEvalError(stderr []byte) *EvalError {
+	e := &EvalError{Stderr: string(stderr)}
+	lines := strings.Split(ansiRe.ReplaceAllString(e.Stderr, ""), "\n")
+
+	// the innermost error is the last one
+	msgAt := -1
+	for i, line := range lines {
+		if l := strings.TrimSpace(line); strings.HasPrefix(l, "error:") && l != "error:" {
+			msgAt = i
+		}
+	}
+	if msgAt < 0 {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if l := strings.TrimSpace(lines[i]); l != "" {
+				e.Message = l
+				break
+			}
+		}
+		return e
+	}
+
+	var message []string
+	first := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[msgAt]), "error:"))
+	i := msgAt + 1
+	for l := first; l != ""; i++ {
+		// nix 2.3 prints the trace, outermost first, in front of the message
+		if strings.HasPrefix(l, "while ") {
+			e.Trace = append(e.Trace, strings.TrimSuffix(l, ":"))
+		} else {
+			message = append(message, l)
+		}
+		if i >= len(lines) {
+			break
+		}
+		l = strings.TrimSpace(lines[i])
+	}
+	e.Message = strings.Join(message, "\n")
+	// nix 2.3 appends the location to the message
+	if m := locationRe.FindStringSubmatchIndex(e.Message); m != nil {
+		e.setLocation(e.Message[m[2]:m[3]], e.Message[m[4]:m[5]], e.Message[m[6]:m[7]])
+		e.Message = strings.TrimSpace(e.Message[:m[0]])
+	}
+
+	for ; i < len(lines) && e.File == ""; i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == "" {
+			continue
+		}
+		if m := locationRe.FindStringSubmatch(l); m != nil && strings.HasPrefix(l, "at ") {
+			e.setLocation(m[1], m[2], m[3])
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) == ""; i++ {
+			}
+			for ; i < len(lines) && snippetRe.MatchString(lines[i]); i++ {
+				e.Snippet = append(e.Snippet, lines[i])
+			}
+			e.Snippet = dedent(e.Snippet)
+		}
+		break
+	}
+
+	// nix 2.13 and later print the trace in front of the error, outermost
+	// first, while nix 2.4 to 2.12 print it after the error, innermost first
+	before, after := traceFrames(lines[:msgAt]), traceFrames(lines[min(i, len(lines)):])
+	for j := len(after) - 1; j >= 0; j-- {
+		before = append(before, after[j])
+	}
+	e.Trace = append(before, e.Trace...)
+	for i := len(e.Trace) - 1; i >= 0 && e.AttrPath == ""; i-- {
+		if m := attributeRe.FindStringSubmatch(e.Trace[i]); m != nil {
+			e.AttrPath = m[1]
+		}
+	}
+	return e
+}
+
+func traceFrames(lines []string) []string {
+	var frames []string
+	for _, line := range lines {
+		l := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(l, "…"):
+			frames = append(frames, strings.TrimSpace(strings.TrimPrefix(l, "…")))
+		case strings.HasPrefix(l, "while "), strings.HasPrefix(l, "from call site"):
+			frames = append(frames, strings.TrimSuffix(l, ":"))
+		}
+	}
+	return frames
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (e *EvalError) setLocation(file, line, column string) {
+	e.File = storeRe.ReplaceAllString(file, "")
+	e.Line, _ = strconv.Atoi(line)
+	e.Column, _ = strconv.Atoi(column)
+}
+
+// dedent removes the indentation that all lines have in common.
+func dedent(lines []string) []string {
+	indent := -1
+	for _, l := range lines {
+		if n := len(l) - len(strings.TrimLeft(l, " ")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := range lines {
+		lines[i] = lines[i][indent:]
+	}
+	return lines
+}
+
+// Location returns 'file:line:column', or "" if the location is unknown.
+func (e *EvalError) Location() string {
+	if e.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+}
+
+func (e *EvalError) Error() string {
+	msg := "evaluation failed: " + strings.ReplaceAll(e.Message, "\n", " ")
+	if loc := e.Location(); loc != "" {
+		msg = loc + ": " + msg
+	}
+	if e.AttrPath != "" {
+		msg += fmt.Sprintf(" (while evaluating '%s')", e.AttrPath)
+	}
+	return msg
+}
+
+// Report returns a multi-line description of the error: the message, its
+// location, the source snippet and the trace.
+func (e *EvalError) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "error: %s\n", e.Message)
+	if loc := e.Location(); loc != "" {
+		fmt.Fprintf(&b, "at %s\n", loc)
+	}
+	if e.AttrPath != "" {
+		fmt.Fprintf(&b, "while evaluating '%s'\n", e.AttrPath)
+	}
+	if len(e.Snippet) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", strings.Join(e.Snippet, "\n"))
+	}
+	if len(e.Trace) > 0 {
+		b.WriteString("\ntrace:\n")
+		for _, frame := range e.Trace {
+			fmt.Fprintf(&b, "  %s\n", frame)
+		}
+	}
+	return b.String()
+}
+
+// AsEvalError turns a failed nix evaluation into an *EvalError, given what
+// nix wrote to stderr. Any other error, like a missing nix, is returned as is.
+func AsEvalError(err error, stderr []byte) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	if len(stderr) == 0 {
+		stderr = exitErr.Stderr
+	}
+	e := ParseEvalError(stderr)
+	if e.Message == "" {
+		e.Message = exitErr.Error()
+	}
+	return e
+}
@@ -0,0 +1,106 @@
+package flake
+
+import (
+	"reflect"
+	"testing"
+)
+
+const store = "/nix/store/0c5lpr4s2ai3s1aqb2vv6nj6v4h2yq5z-source/"
+
+func TestParseEvalError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		stderr string
+		want   EvalError
+	}{
+		"nix 2.3": {
+			stderr: "error: while evaluating the attribute 'init' at " + store + "flake.nix:10:3:\n" +
+				"undefined variable 'foo' at " + store + "nix/backend/apps.nix:5:7\n",
+			want: EvalError{
+				Message:  "undefined variable 'foo'",
+				File:     "nix/backend/apps.nix",
+				Line:     5,
+				Column:   7,
+				AttrPath: "init",
+				Trace:    []string{"while evaluating the attribute 'init' at " + store + "flake.nix:10:3"},
+			},
+		},
+		"nix 2.4": {
+			stderr: `error: attribute 'foo' missing
+
+       at ` + store + `nix/backend/apps.nix:12:5:
+
+           11|   {
+           12|     bar = foo.baz;
+             |     ^
+           13|   }
+
+       … while evaluating the attribute 'bar'
+
+       at ` + store + `nix/backend/apps.nix:12:5:
+
+       … while evaluating the attribute 'init.x86_64-linux'
+(use '--show-trace' to show detailed location information)
+`,
+			want: EvalError{
+				Message:  "attribute 'foo' missing",
+				File:     "nix/backend/apps.nix",
+				Line:     12,
+				Column:   5,
+				AttrPath: "bar",
+				Snippet: []string{
+					"11|   {",
+					"12|     bar = foo.baz;",
+					"  |     ^",
+					"13|   }",
+				},
+				Trace: []string{
+					"while evaluating the attribute 'init.x86_64-linux'",
+					"while evaluating the attribute 'bar'",
+				},
+			},
+		},
+		"nix 2.13 with trace": {
+			stderr: `error:
+       … while evaluating the attribute 'init'
+
+         at ` + store + `flake.nix:10:3:
+
+            9|   outputs = inputs: {
+           10|     init = import ./init.nix;
+             |     ^
+
+       … while calling the 'import' builtin
+
+       error: undefined variable 'foo'
+
+       at ` + store + `nix/backend/apps.nix:5:7:
+
+            4|   {
+            5|     x = foo;
+             |       ^
+`,
+			want: EvalError{
+				Message:  "undefined variable 'foo'",
+				File:     "nix/backend/apps.nix",
+				Line:     5,
+				Column:   7,
+				AttrPath: "init",
+				Snippet:  []string{"4|   {", "5|     x = foo;", " |       ^"},
+				Trace: []string{
+					"while evaluating the attribute 'init'",
+					"while calling the 'import' builtin",
+				},
+			},
+		},
+		"no error line": {
+			stderr: "warning: Git tree is dirty\nsomething went wrong\n",
+			want:   EvalError{Message: "something went wrong"},
+		},
+	} {
+		got := ParseEvalError([]byte(tc.stderr))
+		tc.want.Stderr = tc.stderr
+		if !reflect.DeepEqual(*got, tc.want) {
+			t.Errorf("%s:\ngot  %#v\nwant %#v", name, *got, tc.want)
+		}
+	}
+}
@@ -2,22 +2,25 @@ package flake
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os/exec"
 	"text/template"
 
 	"github.com/hymkor/go-lazy"
+
+	"github.com/paisano-nix/paisano/config"
 )
 
 var (
-	registry      = "__std" // keep for now for historic reasons
-	flakeRegistry = func(flake string) string { return fmt.Sprintf("%[2]s#%[1]s", registry, flake) }
+	flakeRegistry = func(flake string) string { return fmt.Sprintf("%[2]s#%[1]s", config.Get().Registry, flake) }
 )
 
+// outt is an entry of the output of 'nix build --json'
 type outt struct {
-	drvPath string            `json:"drvPath"`
-	outputs map[string]string `json:"outputs"`
+	DrvPath string            `json:"drvPath"`
+	Outputs map[string]string `json:"outputs"`
 }
 
 var CellsFrom = lazy.Of[string]{
@@ -59,10 +62,7 @@ func getCurrentSystem() (string, error) {
 		nix, "eval", "--raw", "--impure", "--expr", "builtins.currentSystem",
 	).Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("%w, stderr:\n%s", exitErr, exitErr.Stderr)
-		}
-		return "", err
+		return "", AsEvalError(err, nil)
 	}
 	currentSystemStr := string(currentSystem)
 	return currentSystemStr, nil
@@ -73,14 +73,37 @@ func getCellsFrom() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	args := append([]string{"eval", "--raw"}, config.Get().NixFlags...)
 	cellsFrom, err := exec.Command(
-		nix, "eval", "--raw", flakeRegistry(".")+".cellsFrom",
+		nix, append(args, flakeRegistry(".")+".cellsFrom")...,
 	).Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("%w, stderr:\n%s", exitErr, exitErr.Stderr)
-		}
-		return "", err
+		return "", AsEvalError(err, nil)
 	}
 	return string(cellsFrom[:]), nil
 }
+
+// CurrentSystem returns the system nix builds for by default.
+func CurrentSystem() (string, error) {
+	return getCurrentSystem()
+}
+
+// Systems returns the systems for which the flake provides actions.
+func Systems() ([]string, error) {
+	nix, err := getNix()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"eval", "--json"}, config.Get().NixFlags...)
+	out, err := exec.Command(
+		nix, append(args, flakeRegistry(".")+".actions", "--apply", "builtins.attrNames")...,
+	).Output()
+	if err != nil {
+		return nil, AsEvalError(err, nil)
+	}
+	var systems []string
+	if err := json.Unmarshal(out, &systems); err != nil {
+		return nil, err
+	}
+	return systems, nil
+}
@@ -2,14 +2,18 @@ package flake
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/paisano-nix/paisano/cache"
-	"github.com/paisano-nix/paisano/env"
+	"github.com/paisano-nix/paisano/config"
 )
 
+// LoadFlakeCmd returns the command evaluating the metadata, and the cache
+// and key of the metadata. The key is nil if the metadata can't be cached,
+// because its sources can't be determined.
 func LoadFlakeCmd() (*cache.Cache, *cache.ActionID, *exec.Cmd, *bytes.Buffer, error) {
 
 	nix, err := getNix()
@@ -29,28 +33,64 @@ func LoadFlakeCmd() (*cache.Cache, *cache.ActionID, *exec.Cmd, *bytes.Buffer, er
 
 	// load the paisano metadata from the flake
 	buf := new(bytes.Buffer)
-	args := []string{
-		"eval",
-		"--json",
-		"--no-update-lock-file",
-		"--no-write-lock-file",
-		"--no-warn-dirty",
-		"--accept-flake-config",
-		flakeRegistry(".") + ".init." + currentSystem}
+	args := initArgs(currentSystem)
 	cmd := exec.Command(nix, args...)
 	cmd.Stdin = devNull
 	cmd.Stdout = buf
 
 	// initialize cache
-	metadataCacheDir, err := env.GetProjectMetadataCacheDir()
+	c, err := OpenCache()
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	c, err := cache.Open(metadataCacheDir)
+	// key the metadata by the content of its sources, too, so that
+	// cache hits can be trusted
+	sources, err := sources(c)
 	if err != nil {
+		// a cache hit couldn't be trusted; the evaluation reports why, if
+		// it's the flake's fault
+		return c, nil, cmd, buf, nil
+	}
+	h := cache.NewHash()
+	io.WriteString(h, strings.Join(keyArgs(args), ""))
+	if err := hashSources(h, sources); err != nil {
 		return nil, nil, nil, nil, err
 	}
-	key := cache.NewActionID([]byte(strings.Join(args, "")))
+	key := cache.ActionID(h.SumID())
 
 	return c, &key, cmd, buf, nil
 }
+
+// initArgs are the arguments of evaluating the paisano metadata of the
+// system.
+func initArgs(system string) []string {
+	args := []string{
+		"eval",
+		"--json",
+		"--no-update-lock-file",
+		"--no-write-lock-file",
+		"--no-warn-dirty",
+		"--accept-flake-config",
+		// for the attribute path of evaluation errors
+		"--show-trace"}
+	args = append(args, config.Get().NixFlags...)
+	return append(args, flakeRegistry(".")+".init."+system)
+}
+
+// diagnosticFlags change what nix reports, but not what it evaluates.
+var diagnosticFlags = map[string]bool{
+	"--show-trace":    true,
+	"--trace-verbose": true,
+}
+
+// keyArgs returns the arguments that key the metadata in the cache: all but
+// the diagnostic flags.
+func keyArgs(args []string) []string {
+	var key []string
+	for _, a := range args {
+		if !diagnosticFlags[a] {
+			key = append(key, a)
+		}
+	}
+	return key
+}
@@ -0,0 +1,14 @@
+package flake
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyArgs(t *testing.T) {
+	got := keyArgs([]string{"eval", "--json", "--show-trace", "--option", "eval-cache", "false", ".#__std.init.x86_64-linux"})
+	want := []string{"eval", "--json", "--option", "eval-cache", "false", ".#__std.init.x86_64-linux"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
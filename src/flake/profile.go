@@ -0,0 +1,211 @@
+package flake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paisano-nix/paisano/cache"
+	"github.com/paisano-nix/paisano/config"
+)
+
+// ProfileOptions select the optional, more expensive parts of a Profile.
+type ProfileOptions struct {
+	// Stats has nix report its statistics of every evaluation.
+	Stats bool
+	// CellNames, if set, decodes the names of the cells from the metadata,
+	// each of which is then evaluated on its own, too.
+	CellNames func(metadata []byte) ([]string, error)
+}
+
+// Profile is the cost of evaluating the flake's metadata, broken down.
+type Profile struct {
+	System string
+	// Steps are the evaluations of every load: of the current system, of
+	// the cells' directory and of the metadata.
+	Steps []Step
+	// Cells are the evaluations of the cells on their own, slowest first.
+	Cells []Step
+}
+
+// Step is the cost of one evaluation.
+type Step struct {
+	Name     string
+	Duration time.Duration
+	// Stats are nix's statistics of the evaluation, if requested and
+	// reported.
+	Stats *EvalStats
+	// Err is why the evaluation failed, if it did.
+	Err error
+}
+
+// EvalStats are the headline figures of the statistics nix reports with
+// NIX_SHOW_STATS.
+type EvalStats struct {
+	CPUTime         float64 `json:"cpuTime"`
+	NrThunks        int64   `json:"nrThunks"`
+	NrFunctionCalls int64   `json:"nrFunctionCalls"`
+	GC              struct {
+		TotalBytes int64 `json:"totalBytes"`
+	} `json:"gc"`
+}
+
+// NewProfile evaluates what loading the flake's metadata does, step by
+// step, and times it. The evaluation cache is bypassed so that it doesn't
+// hide the cost. Cells that fail to evaluate on their own are reported
+// rather than returned as an error.
+func NewProfile(opts ProfileOptions) (*Profile, error) {
+	nix, err := getNix()
+	if err != nil {
+		return nil, err
+	}
+	p := &Profile{}
+
+	out, step := evalStep(nix, opts, "current system",
+		"eval", "--raw", "--impure", "--expr", "builtins.currentSystem")
+	p.Steps = append(p.Steps, step)
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	p.System = string(out)
+
+	args := append([]string{"eval", "--raw", "--option", "eval-cache", "false"}, config.Get().NixFlags...)
+	_, step = evalStep(nix, opts, "cells directory", append(args, flakeRegistry(".")+".cellsFrom")...)
+	p.Steps = append(p.Steps, step)
+	if step.Err != nil {
+		return nil, step.Err
+	}
+
+	args = append(initArgs(p.System), "--option", "eval-cache", "false")
+	out, step = evalStep(nix, opts, "metadata", args...)
+	p.Steps = append(p.Steps, step)
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	if opts.CellNames == nil {
+		return p, nil
+	}
+
+	cells, err := opts.CellNames(out)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range cells {
+		// only the list's spine is evaluated besides the cell, of the bare
+		// array or of the envelope
+		apply := fmt.Sprintf("x: builtins.elemAt (if builtins.isList x then x else x.cells) %d", i)
+		_, step := evalStep(nix, opts, "//"+c, append(args, "--apply", apply)...)
+		p.Cells = append(p.Cells, step)
+	}
+	sort.SliceStable(p.Cells, func(i, j int) bool {
+		return p.Cells[i].Duration > p.Cells[j].Duration
+	})
+	return p, nil
+}
+
+// evalStep runs and times a nix evaluation, having nix write its statistics
+// to a temporary file if requested.
+func evalStep(nix string, opts ProfileOptions, name string, args ...string) ([]byte, Step) {
+	step := Step{Name: name}
+	cmd := exec.Command(nix, args...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	var statsPath string
+	if opts.Stats {
+		f, err := os.CreateTemp("", "paisano-stats-*.json")
+		if err != nil {
+			step.Err = err
+			return nil, step
+		}
+		f.Close()
+		statsPath = f.Name()
+		defer os.Remove(statsPath)
+		cmd.Env = append(os.Environ(), "NIX_SHOW_STATS=1", "NIX_SHOW_STATS_PATH="+statsPath)
+	}
+	start := time.Now()
+	out, err := cmd.Output()
+	step.Duration = time.Since(start)
+	if err != nil {
+		step.Err = AsEvalError(err, stderr.Bytes())
+		return nil, step
+	}
+	if statsPath != "" {
+		if b, err := os.ReadFile(statsPath); err == nil && len(b) > 0 {
+			var stats EvalStats
+			if json.Unmarshal(b, &stats) == nil {
+				step.Stats = &stats
+			}
+		}
+	}
+	return out, step
+}
+
+// Report renders the profile as aligned plain text.
+func (p *Profile) Report() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	stats := p.hasStats()
+	row := func(s Step) {
+		fmt.Fprintf(w, "  %s\t%s", s.Name, formatDuration(s.Duration))
+		if stats {
+			if s.Stats != nil {
+				fmt.Fprintf(w, "\t%.3fs\t%d\t%d\t%s",
+					s.Stats.CPUTime, s.Stats.NrThunks, s.Stats.NrFunctionCalls, cache.FormatSize(s.Stats.GC.TotalBytes))
+			} else {
+				fmt.Fprint(w, "\t-\t-\t-\t-")
+			}
+		}
+		if s.Err != nil {
+			fmt.Fprintf(w, "\tfailed: %s", firstLine(s.Err.Error()))
+		}
+		fmt.Fprintln(w)
+	}
+	header := "evaluation\ttime"
+	if stats {
+		header += "\tcpu\tthunks\tcalls\tallocated"
+	}
+	fmt.Fprintf(w, "  %s\n", header)
+	for _, s := range p.Steps {
+		if s.Name == "metadata" && p.System != "" {
+			s.Name += " (" + p.System + ")"
+		}
+		row(s)
+	}
+	for _, s := range p.Cells {
+		s.Name = "cell " + s.Name
+		row(s)
+	}
+	w.Flush()
+	if len(p.Cells) > 0 {
+		b.WriteString("\nCells are evaluated on their own, slowest first. Each includes fetching the\nflake, like the cells directory.\n")
+	}
+	return b.String()
+}
+
+func (p *Profile) hasStats() bool {
+	for _, steps := range [][]Step{p.Steps, p.Cells} {
+		for _, s := range steps {
+			if s.Stats != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
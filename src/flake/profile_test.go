@@ -0,0 +1,37 @@
+package flake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProfileReport(t *testing.T) {
+	p := &Profile{
+		System: "x86_64-linux",
+		Steps: []Step{
+			{Name: "current system", Duration: 12 * time.Millisecond},
+			{Name: "cells directory", Duration: 300 * time.Millisecond},
+			{Name: "metadata", Duration: 2500 * time.Millisecond, Stats: &EvalStats{
+				CPUTime: 2.25, NrThunks: 1000, NrFunctionCalls: 500,
+			}},
+		},
+		Cells: []Step{
+			{Name: "//backend", Duration: 1800 * time.Millisecond},
+			{Name: "//frontend", Duration: 400 * time.Millisecond, Err: errors.New("error: boom\ntrace")},
+		},
+	}
+	want := `  evaluation               time   cpu     thunks  calls  allocated
+  current system           12ms   -       -       -      -
+  cells directory          300ms  -       -       -      -
+  metadata (x86_64-linux)  2.5s   2.250s  1000    500    0 B
+  cell //backend           1.8s   -       -       -      -
+  cell //frontend          400ms  -       -       -      -  failed: error: boom
+
+Cells are evaluated on their own, slowest first. Each includes fetching the
+flake, like the cells directory.
+`
+	if got := p.Report(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -1,12 +1,22 @@
 package flake
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/kballard/go-shellquote"
+
+	"github.com/paisano-nix/paisano/config"
 	"github.com/paisano-nix/paisano/env"
 )
 
@@ -41,7 +51,7 @@ func (c *RunActionCmd) Assemble(extraArgs []string) (string, []string, error) {
 	return nix, args, nil
 }
 
-func (c *RunActionCmd) Build(nix string, args, extraArgs []string) (string, []string, error) {
+func (c *RunActionCmd) build(nix string, args, extraArgs []string, actionPath string) (string, []string, error) {
 	bash, err := exec.LookPath("bash")
 	if err != nil {
 		return "", nil, err
@@ -50,13 +60,20 @@ func (c *RunActionCmd) Build(nix string, args, extraArgs []string) (string, []st
 	// if err != nil {
 	// 	return "", nil, err
 	// }
-	nom, err := exec.LookPath("nom")
-	if err == nil {
+	switch config.Get().Nom {
+	case config.NomAuto:
+		if nom, err := exec.LookPath("nom"); err == nil {
+			nix = nom
+		}
+	case config.NomAlways:
+		nom, err := exec.LookPath("nom")
+		if err != nil {
+			return "", nil, fmt.Errorf("'nom' is configured to build actions, but not installed: %w", err)
+		}
 		nix = nom
 	}
-	actionPath, err := env.GetStateActionPath()
-	if err != nil {
-		return "", nil, err
+	if flags := config.Get().NixFlags; len(flags) > 0 {
+		args = append(args, shellquote.Join(flags...))
 	}
 	args = append(args, "--out-link", actionPath)
 	args = append(args,
@@ -81,23 +98,186 @@ func (c *RunActionCmd) Build(nix string, args, extraArgs []string) (string, []st
 	return bash, cmd, nil
 }
 
-func (c *RunActionCmd) Exec(extraArgs []string) error {
+// DefaultKillGrace is how long Run waits for an action to exit after
+// interrupting it, before it gets killed.
+const DefaultKillGrace = 5 * time.Second
+
+// Result is the outcome of an action executed with Run.
+type Result struct {
+	ExitCode  int
+	StorePath string
+	Start     time.Time
+	End       time.Time
+	// Stdout and Stderr hold the captured output, if capturing was enabled.
+	Stdout []byte
+	Stderr []byte
+}
+
+// Duration is the wall clock time the action took, including its build.
+func (r *Result) Duration() time.Duration { return r.End.Sub(r.Start) }
+
+// RunOptions configure how Run connects to the action.
+type RunOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Capture records stdout and stderr in the Result, in addition to
+	// writing them to Stdout and Stderr, if set.
+	Capture bool
+	// KillGrace overrides DefaultKillGrace.
+	KillGrace time.Duration
+	// ProcessGroup runs the action in a process group of its own, which
+	// is signalled as a whole, for actions that aren't attached to the
+	// terminal and thus don't get its signals.
+	ProcessGroup bool
+	// OwnSignals leaves the signals received while the action runs to the
+	// caller, who handles them on its own, instead of forwarding them.
+	OwnSignals bool
+}
+
+// Run builds and executes the action as a child process. Signals received
+// meanwhile are forwarded to the action if it runs in a process group of its
+// own. When ctx is done, the action is interrupted and killed if it doesn't
+// exit within the grace period.
+// The returned error is only set if the action couldn't be started; a failing
+// action is reported through the Result's exit code.
+func (c *RunActionCmd) Run(ctx context.Context, extraArgs []string) (*Result, error) {
+	return c.RunWith(ctx, extraArgs, RunOptions{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+}
+
+// runs numbers the runs of this process, for their out-links.
+var runs int64
 
+// RunWith is Run with explicit options. Every run has an out-link of its
+// own, which is removed once the action exited; the 'last-action' out-link
+// then keeps the action's store path alive.
+func (c *RunActionCmd) RunWith(ctx context.Context, extraArgs []string, opts RunOptions) (*Result, error) {
 	nix, args, err := c.Assemble(nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	runPath, err := env.GetStateRunPath(fmt.Sprintf("%s-%s-%s-%s-%d-%d",
+		c.Cell, c.Block, c.Target, c.Action, os.Getpid(), atomic.AddInt64(&runs, 1)))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(runPath)
+	bash, argv, err := c.build(nix, args, extraArgs, runPath)
+	if err != nil {
+		return nil, err
 	}
 
-	bash, cmd, err := c.Build(nix, args, extraArgs)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(bash, argv[1:]...)
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	if opts.Capture {
+		cmd.Stdout = teeTo(opts.Stdout, &stdout)
+		cmd.Stderr = teeTo(opts.Stderr, &stderr)
+	}
+
+	res := &Result{Start: time.Now()}
+	err = runCmd(ctx, cmd, opts)
+	res.End = time.Now()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return nil, err
+	}
+	if storePath, err := os.Readlink(runPath); err == nil {
+		res.StorePath = storePath
+		if err := linkLastAction(nix, storePath); err != nil && opts.Stderr != nil {
+			fmt.Fprintf(opts.Stderr, "while updating the last action's out-link: %v\n", err)
+		}
+	}
+	if opts.Capture {
+		res.Stdout = stdout.Bytes()
+		res.Stderr = stderr.Bytes()
+	}
+	return res, nil
+}
+
+// linkLastAction points the 'last-action' out-link at storePath, which
+// registers it as a garbage collector root, like the out-links of a build.
+func linkLastAction(nix, storePath string) error {
+	actionPath, err := env.GetStateActionPath()
 	if err != nil {
 		return err
 	}
+	out, err := exec.Command(nix, "build", "--out-link", actionPath, storePath).CombinedOutput()
+	if err != nil {
+		return AsEvalError(err, out)
+	}
+	return nil
+}
+
+// runCmd runs cmd until it exits. Signals received meanwhile are forwarded
+// to it if it runs in a process group of its own. When ctx is done, it is
+// interrupted and killed if it doesn't exit within the grace period.
+func runCmd(ctx context.Context, cmd *exec.Cmd, opts RunOptions) error {
+	if opts.ProcessGroup {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	grace := opts.KillGrace
+	if grace <= 0 {
+		grace = DefaultKillGrace
+	}
 
-	env.SetEnv() // PRJ_* + NIX_CONFIG
-	if err := syscall.Exec(bash, cmd, os.Environ()); err != nil {
+	// keep running until the action exits
+	sigs := make(chan os.Signal, 1)
+	if !opts.OwnSignals {
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+		defer signal.Stop(sigs)
+	}
+
+	if err := cmd.Start(); err != nil {
 		return err
 	}
-	return nil
+	send := func(sig syscall.Signal) {
+		if opts.ProcessGroup {
+			syscall.Kill(-cmd.Process.Pid, sig)
+		} else {
+			cmd.Process.Signal(sig)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		var (
+			cancelled = ctx.Done()
+			kill      <-chan time.Time
+		)
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigs:
+				// otherwise, the action shares the process group and thus
+				// got the signal already
+				if opts.ProcessGroup {
+					send(sig.(syscall.Signal))
+				}
+			case <-cancelled:
+				send(syscall.SIGINT)
+				kill = time.After(grace)
+				cancelled = nil
+			case <-kill:
+				send(syscall.SIGKILL)
+			}
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	return err
+}
+
+func teeTo(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(w, buf)
 }
 
 func (c *RunActionCmd) getArgs(currentSystem string) ([]string, error) {
@@ -117,6 +297,15 @@ func (c *RunActionCmd) getArgs(currentSystem string) ([]string, error) {
 	return []string{c.renderFragmentFor(currentSystem)}, nil
 }
 
+// attrPath is the action's attribute path below the flake's actions.
+func (c *RunActionCmd) attrPath(currentSystem string) []string {
+	system := c.System
+	if system == "" {
+		system = currentSystem
+	}
+	return []string{system, c.Cell, c.Block, c.Target, c.Action}
+}
+
 func (c *RunActionCmd) renderFragmentFor(system string) string {
 	return tprintf(c, flakeRegistry(".")+".actions."+system+".{{.Cell}}.{{.Block}}.{{.Target}}.{{.Action}}")
 }
@@ -0,0 +1,170 @@
+package flake
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paisano-nix/paisano/cache"
+	"github.com/paisano-nix/paisano/config"
+	"github.com/paisano-nix/paisano/env"
+)
+
+// OpenCache opens the project's metadata cache.
+func OpenCache() (*cache.Cache, error) {
+	metadataCacheDir, err := env.GetProjectMetadataCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Open(metadataCacheDir)
+}
+
+// Sources returns the files the flake's metadata is evaluated from, in the
+// working tree: 'flake.nix', 'flake.lock' and the cells directory.
+func Sources() ([]string, error) {
+	c, err := OpenCache()
+	if err != nil {
+		return nil, err
+	}
+	return sources(c)
+}
+
+func sources(c *cache.Cache) ([]string, error) {
+	// the flake is always evaluated from the working directory
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	files := []string{
+		filepath.Join(root, "flake.nix"),
+		filepath.Join(root, "flake.lock"),
+	}
+	cells, err := cellsDir(c, root, files)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, cells), nil
+}
+
+// cellsDir returns the cells directory in the working tree. Determining it
+// takes an evaluation of the flake, so it's cached by the content of the
+// flake's files, which set it.
+func cellsDir(c *cache.Cache, root string, flakeFiles []string) (string, error) {
+	h := cache.NewHash()
+	fmt.Fprintf(h, "cellsFrom\x00%s\x00%s\x00", flakeRegistry("."), strings.Join(config.Get().NixFlags, " "))
+	if err := hashSources(h, flakeFiles); err != nil {
+		return "", err
+	}
+	key := cache.ActionID(h.SumID())
+	if cached, _, err := c.GetBytes(key); err == nil {
+		return string(cached), nil
+	}
+	s, err := getCellsFrom()
+	if err != nil {
+		return "", fmt.Errorf("while determining the cells directory: %w", err)
+	}
+	dir := s
+	if storeRe.MatchString(s) {
+		// the flake was copied to the store for evaluation
+		dir = filepath.Join(root, storeRe.ReplaceAllString(s, ""))
+	} else if !filepath.IsAbs(s) {
+		dir = filepath.Join(root, s)
+	}
+	c.PutBytes(key, []byte(dir))
+	return dir, nil
+}
+
+// hashSources writes the path and content of every file, and of every file
+// in the directories, to the hash.
+func hashSources(h io.Writer, paths []string) error {
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err == nil && info.IsDir() {
+			files, err := listFiles(p)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				if err := hashFile(h, f); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := hashFile(h, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashFile(h io.Writer, path string) error {
+	info, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// e.g. deleted, but still tracked by git
+		_, err = fmt.Fprintf(h, "%s\x00missing\x00", path)
+		return err
+	case err != nil:
+		return err
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(h, "%s\x00link\x00%s\x00", path, target)
+		return err
+	case info.IsDir():
+		// e.g. a git submodule
+		_, err = fmt.Fprintf(h, "%s\x00dir\x00", path)
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(h, "%s\x00%d\x00", path, info.Size()); err != nil {
+		return err
+	}
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// listFiles lists the files in the directory that git doesn't ignore or,
+// outside of a git repository, all but hidden ones.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	out, err := exec.Command("git", "-C", dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard").Output()
+	if err == nil {
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f != "" {
+				files = append(files, filepath.Join(dir, f))
+			}
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,57 @@
+package flake
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/paisano-nix/paisano/cache"
+)
+
+func TestHashSources(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() cache.ID {
+		h := cache.NewHash()
+		if err := hashSources(h, []string{filepath.Join(dir, "flake.lock"), filepath.Join(dir, "nix")}); err != nil {
+			t.Fatal(err)
+		}
+		return h.SumID()
+	}
+	write("nix/backend/apps.nix", "{}")
+	write("nix/.gitignore", "result\n")
+	if err := exec.Command("git", "-C", dir, "init", "-q").Run(); err != nil {
+		t.Fatal(err)
+	}
+	before := hash()
+
+	write("nix/backend/result", "ignored")
+	write("README.md", "not a source")
+	if hash() != before {
+		t.Error("ignored files changed the hash")
+	}
+	for _, change := range []struct{ name, content string }{
+		{"nix/backend/apps.nix", "{ apps = {}; }"},
+		{"nix/backend/untracked.nix", "{}"},
+		{"flake.lock", "{}"},
+	} {
+		write(change.name, change.content)
+		if after := hash(); after == before {
+			t.Errorf("%s: the hash didn't change", change.name)
+		} else {
+			before = after
+		}
+	}
+}
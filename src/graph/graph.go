@@ -0,0 +1,349 @@
+// Package graph builds the dependency graph between targets from the
+// 'deps' declared in the repository metadata.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+// Graph holds the dependency relations between targets, which are
+// identified by their '//cell/block/target' title.
+type Graph struct {
+	// Nodes are all targets in the order of the metadata.
+	Nodes []string
+	deps  map[string][]string
+	rdeps map[string][]string
+	known map[string]bool
+}
+
+// Normalize turns a declared dependency into a target id: the leading '//'
+// is optional in the metadata and an action suffix is ignored. Target names
+// may contain ':', so the suffix is split off like the CLI parses specs.
+func Normalize(dep string) string {
+	dep = "//" + strings.TrimPrefix(dep, "//")
+	if g, err := data.SpecRe.Groups(dep); err == nil {
+		return fmt.Sprintf("//%s/%s/%s", g["cell"], g["block"], g["target"])
+	}
+	return dep
+}
+
+// Resolve is Normalize, but keeps a dependency without an action suffix
+// whose target name contains ':', if that target is known.
+func (g *Graph) Resolve(dep string) string {
+	if id := "//" + strings.TrimPrefix(dep, "//"); g.known[id] {
+		return id
+	}
+	return Normalize(dep)
+}
+
+// New builds the graph of all targets of the root.
+func New(r *data.Root) *Graph {
+	g := &Graph{
+		deps:  map[string][]string{},
+		rdeps: map[string][]string{},
+		known: map[string]bool{},
+	}
+	for ci, c := range r.Cells {
+		for bi, b := range c.Blocks {
+			for ti := range b.Targets {
+				id := r.TargetTitle(ci, bi, ti)
+				g.Nodes = append(g.Nodes, id)
+				g.known[id] = true
+			}
+		}
+	}
+	for ci, c := range r.Cells {
+		for bi, b := range c.Blocks {
+			for ti, t := range b.Targets {
+				id := r.TargetTitle(ci, bi, ti)
+				seen := map[string]bool{}
+				for _, d := range t.Deps {
+					dep := g.Resolve(d)
+					if seen[dep] {
+						continue
+					}
+					seen[dep] = true
+					g.deps[id] = append(g.deps[id], dep)
+					g.rdeps[dep] = append(g.rdeps[dep], id)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Has reports whether id is a known target.
+func (g *Graph) Has(id string) bool { return g.known[id] }
+
+// Deps returns the direct dependencies of id.
+func (g *Graph) Deps(id string) []string { return g.deps[id] }
+
+// Dependents returns the targets directly depending on id.
+func (g *Graph) Dependents(id string) []string { return g.rdeps[id] }
+
+// Missing returns every declared dependency that doesn't name a known
+// target, keyed by the target declaring it.
+func (g *Graph) Missing() map[string][]string {
+	missing := map[string][]string{}
+	for _, id := range g.Nodes {
+		for _, d := range g.deps[id] {
+			if !g.known[d] {
+				missing[id] = append(missing[id], d)
+			}
+		}
+	}
+	return missing
+}
+
+// Closure returns id and everything reachable from it, following
+// dependencies or, if reverse is set, dependents.
+func (g *Graph) Closure(id string, reverse bool) []string {
+	var (
+		seen = map[string]bool{}
+		out  []string
+		walk func(string)
+	)
+	walk = func(n string) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		out = append(out, n)
+		next := g.deps[n]
+		if reverse {
+			next = g.rdeps[n]
+		}
+		for _, m := range next {
+			walk(m)
+		}
+	}
+	walk(id)
+	return out
+}
+
+// CycleError reports a dependency cycle, starting and ending with the same target.
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// Order returns id and all of its transitive dependencies in topological
+// order, so that each target comes after all of its dependencies and id
+// comes last. Unknown dependencies are left out.
+func (g *Graph) Order(id string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	var (
+		state = map[string]int{}
+		stack []string
+		order []string
+		visit func(string) error
+	)
+	visit = func(n string) error {
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			for i, m := range stack {
+				if m == n {
+					cycle := append([]string{}, stack[i:]...)
+					return &CycleError{append(cycle, n)}
+				}
+			}
+		}
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, d := range g.deps[n] {
+			if !g.known[d] {
+				continue
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+		order = append(order, n)
+		return nil
+	}
+	if err := visit(id); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Sub returns the graph restricted to the given targets.
+func (g *Graph) Sub(ids []string) *Graph {
+	keep := map[string]bool{}
+	for _, id := range ids {
+		keep[id] = true
+	}
+	sub := &Graph{
+		deps:  map[string][]string{},
+		rdeps: map[string][]string{},
+		known: map[string]bool{},
+	}
+	for _, id := range g.Nodes {
+		if !keep[id] {
+			continue
+		}
+		sub.Nodes = append(sub.Nodes, id)
+		sub.known[id] = true
+	}
+	for _, id := range sub.Nodes {
+		for _, d := range g.deps[id] {
+			if keep[d] || !g.known[d] {
+				sub.deps[id] = append(sub.deps[id], d)
+				sub.rdeps[d] = append(sub.rdeps[d], id)
+			}
+		}
+	}
+	return sub
+}
+
+// WriteTree prints the dependency tree of id, or the tree of its
+// dependents if reverse is set. Targets already printed are not expanded
+// again and marked with '(*)'; cycles and unknown targets are marked, too.
+func (g *Graph) WriteTree(w io.Writer, id string, reverse bool) {
+	printed := map[string]bool{}
+	var walk func(n, indent string, path map[string]bool)
+	walk = func(n, indent string, path map[string]bool) {
+		next := g.deps[n]
+		if reverse {
+			next = g.rdeps[n]
+		}
+		for i, m := range next {
+			branch, cont := "├── ", "│   "
+			if i == len(next)-1 {
+				branch, cont = "└── ", "    "
+			}
+			switch {
+			case path[m]:
+				fmt.Fprintf(w, "%s%s%s (cycle)\n", indent, branch, m)
+			case !g.known[m]:
+				fmt.Fprintf(w, "%s%s%s (missing)\n", indent, branch, m)
+			case printed[m] && len(g.next(m, reverse)) > 0:
+				fmt.Fprintf(w, "%s%s%s (*)\n", indent, branch, m)
+			default:
+				fmt.Fprintf(w, "%s%s%s\n", indent, branch, m)
+				printed[m] = true
+				path[m] = true
+				walk(m, indent+cont, path)
+				delete(path, m)
+			}
+		}
+	}
+	fmt.Fprintln(w, id)
+	walk(id, "", map[string]bool{id: true})
+}
+
+func (g *Graph) next(n string, reverse bool) []string {
+	if reverse {
+		return g.rdeps[n]
+	}
+	return g.deps[n]
+}
+
+// WriteDot renders the graph in graphviz' dot language, with edges
+// pointing from a target to its dependencies.
+func (g *Graph) WriteDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph deps {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, id := range g.Nodes {
+		fmt.Fprintf(w, "  %q;\n", id)
+	}
+	for _, id := range g.Nodes {
+		for _, d := range g.deps[id] {
+			if !g.known[d] {
+				fmt.Fprintf(w, "  %q [style=dashed];\n", d)
+			}
+			fmt.Fprintf(w, "  %q -> %q;\n", id, d)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// WriteMermaid renders the graph as a mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) {
+	ids := map[string]string{}
+	nodeId := func(n string) string {
+		if id, ok := ids[n]; ok {
+			return id
+		}
+		ids[n] = fmt.Sprintf("n%d", len(ids))
+		return ids[n]
+	}
+	fmt.Fprintln(w, "flowchart LR")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", nodeId(n), mermaidLabel(n))
+	}
+	for _, n := range g.Nodes {
+		for _, d := range g.deps[n] {
+			if !g.known[d] {
+				fmt.Fprintf(w, "  %s[\"%s (missing)\"]\n", nodeId(d), mermaidLabel(d))
+			}
+			fmt.Fprintf(w, "  %s --> %s\n", nodeId(n), nodeId(d))
+		}
+	}
+}
+
+// mermaidLabel escapes the characters of a quoted mermaid label that would
+// end it or be taken for markup, with mermaid's entity codes.
+var mermaidLabel = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+).Replace
+
+type jsonNode struct {
+	Id         string   `json:"id"`
+	Known      bool     `json:"known"`
+	Deps       []string `json:"deps"`
+	Dependents []string `json:"dependents"`
+}
+
+// WriteJson renders the graph as a json list of nodes.
+func (g *Graph) WriteJson(w io.Writer) error {
+	var (
+		nodes   []jsonNode
+		missing = map[string]bool{}
+	)
+	for _, n := range g.Nodes {
+		nodes = append(nodes, jsonNode{n, true, nonNil(g.deps[n]), nonNil(g.rdeps[n])})
+		for _, d := range g.deps[n] {
+			if !g.known[d] {
+				missing[d] = true
+			}
+		}
+	}
+	var unknown []string
+	for d := range missing {
+		unknown = append(unknown, d)
+	}
+	sort.Strings(unknown)
+	for _, d := range unknown {
+		nodes = append(nodes, jsonNode{d, false, []string{}, nonNil(g.rdeps[d])})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(nodes)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+func target(name string, deps ...string) data.Target {
+	return data.Target{Name: name, Deps: deps}
+}
+
+func testRoot() *data.Root {
+	return &data.Root{Cells: []data.Cell{
+		{Name: "c", Blocks: []data.Block{
+			{Name: "b", Targets: []data.Target{
+				target("app", "//c/b/lib", "c/b/config:build"),
+				target("lib", "//c/b/base"),
+				target("config", "//c/b/base", "//c/b/nowhere"),
+				target("base"),
+			}},
+		}},
+	}}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"//c/b/t":        "//c/b/t",
+		"c/b/t":          "//c/b/t",
+		"//c/b/t:build":  "//c/b/t",
+		"//c/b/t:v2:run": "//c/b/t:v2",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRelations(t *testing.T) {
+	g := New(testRoot())
+	if got := g.Deps("//c/b/app"); len(got) != 2 || got[0] != "//c/b/lib" || got[1] != "//c/b/config" {
+		t.Errorf("Deps(app) = %v", got)
+	}
+	if got := g.Dependents("//c/b/base"); len(got) != 2 || got[0] != "//c/b/lib" || got[1] != "//c/b/config" {
+		t.Errorf("Dependents(base) = %v", got)
+	}
+	if got := g.Missing(); len(got) != 1 || got["//c/b/config"][0] != "//c/b/nowhere" {
+		t.Errorf("Missing() = %v", got)
+	}
+	if got := g.Closure("//c/b/base", true); len(got) != 4 {
+		t.Errorf("Closure(base, reverse) = %v", got)
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	var buf bytes.Buffer
+	New(testRoot()).WriteTree(&buf, "//c/b/app", false)
+	want := `//c/b/app
+├── //c/b/lib
+│   └── //c/b/base
+└── //c/b/config
+    ├── //c/b/base
+    └── //c/b/nowhere (missing)
+`
+	if buf.String() != want {
+		t.Errorf("WriteTree:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	order, err := New(testRoot()).Order("//c/b/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := map[string]int{}
+	for i, id := range order {
+		pos[id] = i
+	}
+	if len(order) != 4 || pos["//c/b/app"] != 3 || pos["//c/b/base"] > pos["//c/b/lib"] || pos["//c/b/base"] > pos["//c/b/config"] {
+		t.Errorf("Order(app) = %v", order)
+	}
+
+	cyclic := testRoot()
+	cyclic.Cells[0].Blocks[0].Targets[3].Deps = []string{"//c/b/app"}
+	_, err = New(cyclic).Order("//c/b/app")
+	var cerr *CycleError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Order on cyclic graph: got %v, want CycleError", err)
+	}
+	if want := "dependency cycle: //c/b/app -> //c/b/lib -> //c/b/base -> //c/b/app"; cerr.Error() != want {
+		t.Errorf("got %q, want %q", cerr.Error(), want)
+	}
+}
+
+func TestTargetNamesWithColons(t *testing.T) {
+	g := New(&data.Root{Cells: []data.Cell{
+		{Name: "c", Blocks: []data.Block{
+			{Name: "b", Targets: []data.Target{
+				target("app", "//c/b/api:v2", "//c/b/api:v2:build"),
+				target(`api:v2 "beta"`),
+				target("api:v2"),
+			}},
+		}},
+	}})
+	if got := g.Deps("//c/b/app"); len(got) != 1 || got[0] != "//c/b/api:v2" {
+		t.Errorf("Deps(app) = %v", got)
+	}
+	var b bytes.Buffer
+	g.WriteMermaid(&b)
+	if want := `n1["//c/b/api:v2 #quot;beta#quot;"]`; !bytes.Contains(b.Bytes(), []byte(want)) {
+		t.Errorf("got:\n%s\nwant a line %s", b.String(), want)
+	}
+}
@@ -0,0 +1,195 @@
+// Package history records every action run from the CLI or the TUI in a
+// newline-delimited json log in the project's state directory.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/rogpeppe/go-internal/lockedfile"
+
+	"github.com/paisano-nix/paisano/env"
+	"github.com/paisano-nix/paisano/flake"
+)
+
+const (
+	SourceCli   = "cli"
+	SourceTui   = "tui"
+	SourceWatch = "watch"
+)
+
+// Entry records a single action invocation.
+type Entry struct {
+	Spec      string    `json:"spec"`
+	Cell      string    `json:"cell"`
+	Block     string    `json:"cellBlock"`
+	Target    string    `json:"target"`
+	Action    string    `json:"action"`
+	Args      []string  `json:"args"`
+	System    string    `json:"system,omitempty"`
+	Source    string    `json:"source"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	ExitCode  int       `json:"exitCode"`
+	StorePath string    `json:"storePath,omitempty"`
+}
+
+// NewEntry records the result of running cmd with args.
+func NewEntry(cmd *flake.RunActionCmd, args []string, res *flake.Result, source string) Entry {
+	if args == nil {
+		args = []string{}
+	}
+	return Entry{
+		Spec:      cmd.Spec(),
+		Cell:      cmd.Cell,
+		Block:     cmd.Block,
+		Target:    cmd.Target,
+		Action:    cmd.Action,
+		Args:      args,
+		System:    cmd.System,
+		Source:    source,
+		Start:     res.Start,
+		End:       res.End,
+		ExitCode:  res.ExitCode,
+		StorePath: res.StorePath,
+	}
+}
+
+// Cmd returns the command to re-run the recorded invocation.
+func (e Entry) Cmd() *flake.RunActionCmd {
+	return &flake.RunActionCmd{
+		System: e.System,
+		Cell:   e.Cell,
+		Block:  e.Block,
+		Target: e.Target,
+		Action: e.Action,
+	}
+}
+
+// Duration is the wall clock time of the invocation.
+func (e Entry) Duration() time.Duration { return e.End.Sub(e.Start) }
+
+// CommandLine renders the invocation the way it would be typed in the shell.
+func (e Entry) CommandLine(argv0 string) string {
+	s := []string{argv0}
+	if e.System != "" {
+		s = append(s, "--for", e.System)
+	}
+	s = append(s, e.Spec)
+	s = append(s, e.Args...)
+	return shellquote.Join(s...)
+}
+
+// Append adds an entry to the history log.
+func Append(e Entry) error {
+	path, err := env.GetStateHistoryPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := lockedfile.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// start a line of its own after an entry that was cut off
+	if info, err := f.Stat(); err != nil {
+		return err
+	} else if size := info.Size(); size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load returns all recorded entries, most recent first.
+// Lines that can't be decoded are skipped.
+func Load() ([]Entry, error) {
+	path, err := env.GetStateHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := lockedfile.Read(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, scanner.Err()
+}
+
+// Numbered is an entry along with its number, as taken by 'rerun'.
+type Numbered struct {
+	N int `json:"n"`
+	Entry
+}
+
+// Select numbers the entries, which are ordered most recent first, and
+// returns those that keep accepts; at most limit of them, unless it is 0.
+func Select(entries []Entry, keep func(Entry) bool, limit int) []Numbered {
+	var selected []Numbered
+	for i, e := range entries {
+		if !keep(e) {
+			continue
+		}
+		selected = append(selected, Numbered{i + 1, e})
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+	}
+	return selected
+}
+
+// Nth returns the entry numbered n by Select.
+func Nth(entries []Entry, n int) (Entry, error) {
+	if n < 1 {
+		return Entry{}, fmt.Errorf("invalid history entry: %d", n)
+	}
+	if n > len(entries) {
+		return Entry{}, fmt.Errorf("history has only %d entries", len(entries))
+	}
+	return entries[n-1], nil
+}
+
+// LastArgs returns the arguments of the most recent run of the action, if any.
+func LastArgs(spec string) ([]string, error) {
+	entries, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Spec == spec {
+			return e.Args, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,145 @@
+package history
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/paisano-nix/paisano/env"
+	"github.com/paisano-nix/paisano/flake"
+)
+
+func useStateDir(t *testing.T) string {
+	t.Setenv("PRJ_STATE_HOME", t.TempDir())
+	path, err := env.GetStateHistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func entry(target string, exitCode int, args ...string) Entry {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cmd := &flake.RunActionCmd{Cell: "backend", Block: "apps", Target: target, Action: "run"}
+	return NewEntry(cmd, args, &flake.Result{ExitCode: exitCode, Start: start, End: start.Add(time.Second)}, SourceCli)
+}
+
+func targets(entries []Entry) []string {
+	var s []string
+	for _, e := range entries {
+		s = append(s, e.Target)
+	}
+	return s
+}
+
+func TestAppendLoad(t *testing.T) {
+	useStateDir(t)
+	entries, err := Load()
+	if err != nil || entries != nil {
+		t.Fatalf("Load() of a missing history = %v, %v", entries, err)
+	}
+	want := []Entry{entry("api", 0, "--port", "8080"), entry("worker", 1), entry("web", 0)}
+	for _, e := range want {
+		if err := Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(entries); !reflect.DeepEqual(got, []string{"web", "worker", "api"}) {
+		t.Fatalf("got %v, want most recent first", got)
+	}
+	if !reflect.DeepEqual(entries[2], want[0]) {
+		t.Errorf("round trip: got %+v, want %+v", entries[2], want[0])
+	}
+	if entries[1].Args == nil {
+		t.Errorf("args of a run without arguments decode as nil")
+	}
+}
+
+func TestLoadSkipsBrokenLines(t *testing.T) {
+	path := useStateDir(t)
+	if err := Append(entry("api", 0)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a corrupt line, followed by one that was cut off while being written
+	f.WriteString("not json\n")
+	f.WriteString(`{"spec":"//backend/apps/worker:run","target":"wor`)
+	f.Close()
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(entries); !reflect.DeepEqual(got, []string{"api"}) {
+		t.Errorf("got %v, want [api]", got)
+	}
+
+	// the next entry starts on a line of its own
+	if err := Append(entry("web", 0)); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(entries); !reflect.DeepEqual(got, []string{"web", "api"}) {
+		t.Errorf("got %v, want [web api]", got)
+	}
+}
+
+func TestSelectNth(t *testing.T) {
+	entries := []Entry{entry("web", 0), entry("worker", 1), entry("api", 0), entry("api", 2)}
+	failed := func(e Entry) bool { return e.ExitCode != 0 }
+	all := func(Entry) bool { return true }
+
+	for _, tc := range []struct {
+		name  string
+		keep  func(Entry) bool
+		limit int
+		want  []int
+	}{
+		{"all", all, 0, []int{1, 2, 3, 4}},
+		{"limit", all, 2, []int{1, 2}},
+		{"failed", failed, 0, []int{2, 4}},
+		{"failed, limit", failed, 1, []int{2}},
+		{"none", func(Entry) bool { return false }, 0, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []int
+			for _, s := range Select(entries, tc.keep, tc.limit) {
+				got = append(got, s.N)
+				// rerun N runs the entry history lists as N
+				if e, err := Nth(entries, s.N); err != nil || !reflect.DeepEqual(e, s.Entry) {
+					t.Errorf("Nth(%d) = %+v, %v, want %+v", s.N, e, err, s.Entry)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	for _, n := range []int{0, -1, 5} {
+		if _, err := Nth(entries, n); err == nil {
+			t.Errorf("Nth(%d) succeeded", n)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	e := entry("api", 0, "--msg", "hello world", "it's")
+	e.System = "aarch64-linux"
+	got := e.CommandLine("paisano")
+	want := `paisano --for aarch64-linux //backend/apps/api:run --msg 'hello world' it\'s`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+// Package jobs runs actions in the background of the TUI, captures their
+// output and reports their lifecycle as tea messages.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/history"
+)
+
+type State int
+
+const (
+	Running State = iota
+	Succeeded
+	Failed
+	// Cancelled jobs were interrupted on request.
+	Cancelled
+)
+
+// StartedMsg reports that a job started.
+type StartedMsg struct{ Job *Job }
+
+// FinishedMsg reports that a job finished, in whichever State.
+type FinishedMsg struct{ Job *Job }
+
+// Job is an action running, or having run, in the background.
+type Job struct {
+	ID    int
+	Cmd   flake.RunActionCmd
+	Args  []string
+	Start time.Time
+
+	mu        sync.Mutex
+	end       time.Time
+	result    *flake.Result
+	err       error
+	cancelled bool
+	log       *Log
+	cancel    context.CancelFunc
+}
+
+// State tells whether the job is still running and how it ended otherwise.
+func (j *Job) State() State {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case j.end.IsZero():
+		return Running
+	case j.cancelled:
+		return Cancelled
+	case j.err != nil || j.result.ExitCode != 0:
+		return Failed
+	}
+	return Succeeded
+}
+
+// Elapsed is how long the job ran, or has been running.
+func (j *Job) Elapsed() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.end.IsZero() {
+		return time.Since(j.Start)
+	}
+	return j.end.Sub(j.Start)
+}
+
+// Result returns the outcome of a finished job: its exit code, or why it
+// couldn't be run.
+func (j *Job) Result() (*flake.Result, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.err
+}
+
+// Log is the captured output of the job.
+func (j *Job) Log() *Log { return j.log }
+
+// Cancel interrupts the job, which gets killed if it doesn't exit within
+// the manager's KillGrace.
+func (j *Job) Cancel() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.end.IsZero() {
+		j.cancelled = true
+		j.cancel()
+	}
+}
+
+// Spec renders the job's action and arguments.
+func (j *Job) Spec() string {
+	return strings.Join(append([]string{j.Cmd.Spec()}, j.Args...), " ")
+}
+
+// Manager runs the jobs of a TUI session.
+type Manager struct {
+	// KillGrace overrides flake.DefaultKillGrace for cancelled jobs.
+	KillGrace time.Duration
+
+	mu     sync.Mutex
+	jobs   []*Job
+	events chan tea.Msg
+	wg     sync.WaitGroup
+}
+
+func NewManager() *Manager {
+	return &Manager{events: make(chan tea.Msg, 64)}
+}
+
+// Start runs the action with args in the background, recording it in the
+// history once it finished.
+func (m *Manager) Start(cmd flake.RunActionCmd, args []string) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	j := &Job{
+		ID:     len(m.jobs) + 1,
+		Cmd:    cmd,
+		Args:   args,
+		Start:  time.Now(),
+		log:    &Log{},
+		cancel: cancel,
+	}
+	m.jobs = append(m.jobs, j)
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.events <- StartedMsg{j}
+		res, err := j.Cmd.RunWith(ctx, args, flake.RunOptions{
+			Stdout:       j.log,
+			Stderr:       j.log,
+			KillGrace:    m.KillGrace,
+			ProcessGroup: true,
+			// the TUI quits on signals, shutting down the jobs
+			OwnSignals: true,
+		})
+		if err == nil {
+			if err := history.Append(history.NewEntry(&j.Cmd, args, res, history.SourceTui)); err != nil {
+				fmt.Fprintf(j.log, "while recording history: %v\n", err)
+			}
+		}
+		j.mu.Lock()
+		j.end, j.result, j.err = time.Now(), res, err
+		j.mu.Unlock()
+		cancel()
+		m.events <- FinishedMsg{j}
+	}()
+	return j
+}
+
+// Jobs returns all jobs of the session, most recent first.
+func (m *Manager) Jobs() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]*Job, len(m.jobs))
+	for i, j := range m.jobs {
+		jobs[len(m.jobs)-1-i] = j
+	}
+	return jobs
+}
+
+// Running counts the jobs that are still running.
+func (m *Manager) Running() int {
+	var n int
+	for _, j := range m.Jobs() {
+		if j.State() == Running {
+			n++
+		}
+	}
+	return n
+}
+
+// Wait returns a command receiving the next lifecycle event of any job.
+func (m *Manager) Wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-m.events
+	}
+}
+
+// Shutdown cancels all running jobs and waits for them to exit. No job may
+// be started afterwards.
+func (m *Manager) Shutdown() {
+	for _, j := range m.Jobs() {
+		j.Cancel()
+	}
+	// nobody receives the events anymore
+	go func() {
+		for range m.events {
+		}
+	}()
+	m.wg.Wait()
+	close(m.events)
+}
+
+// Log collects the output of a job.
+type Log struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *Log) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// Lines returns the output's lines, each as it was last redrawn with '\r'.
+func (l *Log) Lines() []string {
+	l.mu.Lock()
+	lines := strings.Split(strings.TrimRight(l.buf.String(), "\n"), "\n")
+	l.mu.Unlock()
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		lines[i] = line[strings.LastIndexByte(line, '\r')+1:]
+	}
+	return lines
+}
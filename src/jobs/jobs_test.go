@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/flake"
+)
+
+func TestLogLines(t *testing.T) {
+	var l Log
+	l.Write([]byte("building\n[1/3]\r[2/3]\r[3/3]\r\ndone"))
+	l.Write([]byte("\n"))
+	want := []string{"building", "[3/3]", "done"}
+	if got := l.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// fakeNix puts a nix on the PATH that "builds" every action into the
+// script body, and keeps the project's state in a temporary directory.
+func fakeNix(t *testing.T, body string) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	action := write("action", "#!/bin/sh\n"+body+"\n")
+	write("nix", `#!/bin/sh
+case "$1" in
+eval) printf x86_64-linux ;;
+build)
+	shift
+	while [ $# -gt 0 ]; do
+		case "$1" in
+		--out-link) link="$2"; shift ;;
+		--*) ;;
+		*) installable="$1" ;;
+		esac
+		shift
+	done
+	case "$installable" in
+	/*) ln -sfn "$installable" "$link" ;;
+	*) ln -sfn `+action+` "$link" ;;
+	esac
+	;;
+esac
+`)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("PRJ_ROOT", dir)
+	t.Setenv("PRJ_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("PRJ_STATE_HOME", filepath.Join(dir, "state"))
+}
+
+var testCmd = flake.RunActionCmd{Cell: "backend", Block: "apps", Target: "api", Action: "run"}
+
+// next waits for the next lifecycle message of the manager's jobs.
+func next(t *testing.T, m *Manager) tea.Msg {
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- m.Wait()() }()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("no job lifecycle message")
+	}
+	return nil
+}
+
+// waitStarted waits for the job's action to print "started".
+func waitStarted(j *Job) {
+	for !strings.Contains(strings.Join(j.Log().Lines(), "\n"), "started") {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	fakeNix(t, `echo "args: $*"; [ "$1" = fail ] && exit 3; exit 0`)
+	m := NewManager()
+
+	for _, tc := range []struct {
+		args  []string
+		state State
+		code  int
+	}{
+		{[]string{"ok"}, Succeeded, 0},
+		{[]string{"fail"}, Failed, 3},
+	} {
+		j := m.Start(testCmd, tc.args)
+		if msg, ok := next(t, m).(StartedMsg); !ok || msg.Job != j {
+			t.Fatalf("got %#v, want the job to start", msg)
+		}
+		if msg, ok := next(t, m).(FinishedMsg); !ok || msg.Job != j {
+			t.Fatalf("got %#v, want the job to finish", msg)
+		}
+		if got := j.State(); got != tc.state {
+			t.Errorf("%v: got state %v, want %v", tc.args, got, tc.state)
+		}
+		res, err := j.Result()
+		if err != nil || res.ExitCode != tc.code {
+			t.Errorf("%v: got result %+v, %v, want exit code %d", tc.args, res, err, tc.code)
+		}
+		if lines := j.Log().Lines(); lines[len(lines)-1] != "args: "+tc.args[0] {
+			t.Errorf("%v: got output %q", tc.args, lines)
+		}
+	}
+
+	jobs := m.Jobs()
+	if len(jobs) != 2 || jobs[0].ID != 2 || jobs[1].ID != 1 {
+		t.Errorf("got jobs %v, want the most recent first", jobs)
+	}
+	if n := m.Running(); n != 0 {
+		t.Errorf("got %d running jobs, want none", n)
+	}
+	m.Shutdown()
+}
+
+func TestManagerCancel(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		action string
+	}{
+		// exits on the interrupt
+		{"interrupted", `echo started; exec sleep 30`},
+		// ignores the interrupt, so that it has to be killed
+		{"killed", `trap "" INT; echo started; while :; do sleep 0.1; done`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeNix(t, tc.action)
+			m := NewManager()
+			m.KillGrace = 300 * time.Millisecond
+			j := m.Start(testCmd, nil)
+			next(t, m)
+			waitStarted(j)
+			if n := m.Running(); n != 1 {
+				t.Errorf("got %d running jobs, want 1", n)
+			}
+			start := time.Now()
+			j.Cancel()
+			if _, ok := next(t, m).(FinishedMsg); !ok {
+				t.Fatal("the job didn't finish")
+			}
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("took %s to cancel", d)
+			}
+			if got := j.State(); got != Cancelled {
+				t.Errorf("got state %v, want it cancelled", got)
+			}
+			m.Shutdown()
+		})
+	}
+}
+
+func TestManagerShutdown(t *testing.T) {
+	fakeNix(t, `trap "" INT; echo started; while :; do sleep 0.1; done`)
+	m := NewManager()
+	m.KillGrace = 300 * time.Millisecond
+	jobs := []*Job{m.Start(testCmd, nil), m.Start(testCmd, []string{"again"})}
+	for _, j := range jobs {
+		waitStarted(j)
+	}
+	start := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		m.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Shutdown didn't return")
+	}
+	if d := time.Since(start); d < m.KillGrace {
+		t.Errorf("took %s to shut down, want the jobs killed after %s", d, m.KillGrace)
+	}
+	for _, j := range jobs {
+		if got := j.State(); got != Cancelled {
+			t.Errorf("job %d: got state %v, want it cancelled", j.ID, got)
+		}
+	}
+}
@@ -1,9 +1,18 @@
 package keys
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 const spacebar = " "
@@ -18,7 +27,7 @@ var (
 	home            = key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "go to start"))
 	end             = key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "go to end"))
 	enter           = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "execute"))
-	textcopy        = key.NewBinding(key.WithKeys("c", "ctrl+c"), key.WithHelp("c", "copy cmd"))
+	textcopy        = key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy cmd"))
 	search          = key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter"))
 	showReadme      = key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "inspect"))
 	closeReadme     = key.NewBinding(key.WithKeys("?", "esc"), key.WithHelp("?", "close"))
@@ -27,13 +36,309 @@ var (
 	toggleFocus     = key.NewBinding(key.WithKeys("tab", "shift+tab"), key.WithHelp("⇥", "toggle focus"))
 	cycleTab        = key.NewBinding(key.WithKeys("tab"), key.WithHelp("⇥", "cycle tabs"))
 	reverseCycleTab = key.NewBinding(key.WithKeys("shift+tab"))
+	showHistory     = key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "history"))
+	closeHistory    = key.NewBinding(key.WithKeys("h", "esc"), key.WithHelp("h", "close"))
+	rerun           = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "re-run"))
+	showDeps        = key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "deps"))
+	closeDeps       = key.NewBinding(key.WithKeys("d", "esc"), key.WithHelp("d", "close"))
+	jumpTo          = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "jump to"))
+	showSystems     = key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "system"))
+	closeSystems    = key.NewBinding(key.WithKeys("s", "esc"), key.WithHelp("s", "close"))
+	selectSystem    = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "select"))
+	editArgs        = key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "arguments"))
+	closeArgs       = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "keep & close"))
+	runWithArgs     = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "execute"))
+	retry           = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "retry"))
+	dismissError    = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "dismiss"))
+	refresh         = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh"))
+	showProfile     = key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "profile"))
+	closeProfile    = key.NewBinding(key.WithKeys("p", "esc"), key.WithHelp("p", "close"))
+	mark            = key.NewBinding(key.WithKeys(spacebar), key.WithHelp("␣", "mark"))
+	clearMarks      = key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "unmark all"))
+	closeOutput     = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close"))
+	cancelJob       = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "cancel"))
+	showJobs        = key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "jobs"))
+	closeJobs       = key.NewBinding(key.WithKeys("J", "esc"), key.WithHelp("J", "close"))
+	openLog         = key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open log"))
+	toggleTree      = key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tree view"))
+	toggleNode      = key.NewBinding(key.WithKeys("enter"), key.WithHelp("⏎", "expand/collapse"))
+	expandAll       = key.NewBinding(key.WithKeys("+"), key.WithHelp("+", "expand all"))
+	collapseAll     = key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "collapse all"))
 )
 
+// bindings names every binding that can be rebound in the keymap.
+var bindings = map[string]*key.Binding{
+	"up":                &cursorUp,
+	"down":              &cursorDown,
+	"left":              &cursorLeft,
+	"right":             &cursorRight,
+	"page-up":           &pageUp,
+	"page-down":         &pageDown,
+	"home":              &home,
+	"end":               &end,
+	"execute":           &enter,
+	"copy":              &textcopy,
+	"filter":            &search,
+	"inspect":           &showReadme,
+	"close-inspect":     &closeReadme,
+	"quit":              &quit,
+	"force-quit":        &forceQuit,
+	"toggle-focus":      &toggleFocus,
+	"cycle-tab":         &cycleTab,
+	"reverse-cycle-tab": &reverseCycleTab,
+	"history":           &showHistory,
+	"close-history":     &closeHistory,
+	"rerun":             &rerun,
+	"deps":              &showDeps,
+	"close-deps":        &closeDeps,
+	"jump-to":           &jumpTo,
+	"system":            &showSystems,
+	"close-system":      &closeSystems,
+	"select-system":     &selectSystem,
+	"arguments":         &editArgs,
+	"close-args":        &closeArgs,
+	"execute-args":      &runWithArgs,
+	"retry":             &retry,
+	"dismiss-error":     &dismissError,
+	"refresh":           &refresh,
+	"profile":           &showProfile,
+	"close-profile":     &closeProfile,
+	"mark":              &mark,
+	"clear-marks":       &clearMarks,
+	"close-output":      &closeOutput,
+	"cancel-job":        &cancelJob,
+	"jobs":              &showJobs,
+	"close-jobs":        &closeJobs,
+	"open-log":          &openLog,
+	"tree":              &toggleTree,
+	"toggle-node":       &toggleNode,
+	"expand-all":        &expandAll,
+	"collapse-all":      &collapseAll,
+}
+
+var browse = []string{"up", "down", "page-up", "page-down", "home", "end"}
+
+// yields lists the bindings that give up the keys they share with others
+// in a context where both are active, like the spacebar, which pages down
+// anywhere but marks the targets.
+var yields = map[string][]string{
+	"page-down": {"mark"},
+}
+
+// contexts lists the bindings that are active together, by the focus of the TUI.
+var contexts = map[string][]string{
+	"targets":   append([]string{"filter", "toggle-focus", "left", "right", "inspect", "deps", "system", "history", "refresh", "profile", "jobs", "mark", "clear-marks", "tree", "toggle-node", "expand-all", "collapse-all", "quit", "force-quit"}, browse...),
+	"actions":   append([]string{"toggle-focus", "left", "right", "execute", "arguments", "copy", "inspect", "system", "history", "refresh", "profile", "jobs", "quit", "force-quit"}, browse...),
+	"readme":    append([]string{"left", "right", "close-inspect", "cycle-tab", "reverse-cycle-tab", "quit", "force-quit"}, browse...),
+	"inspect":   append([]string{"close-inspect", "copy", "quit", "force-quit"}, browse...),
+	"history":   append([]string{"rerun", "close-history", "quit", "force-quit"}, browse...),
+	"deps":      append([]string{"jump-to", "close-deps", "quit", "force-quit"}, browse...),
+	"systems":   append([]string{"select-system", "close-system", "quit", "force-quit"}, browse...),
+	"arguments": {"execute-args", "close-args", "force-quit"},
+	"error":     append([]string{"left", "right", "retry", "dismiss-error", "copy", "quit", "force-quit"}, browse...),
+	"output":    append([]string{"left", "right", "cycle-tab", "reverse-cycle-tab", "cancel-job", "close-output", "quit", "force-quit"}, browse...),
+	"profile":   append([]string{"left", "right", "refresh", "close-profile", "quit", "force-quit"}, browse...),
+	"jobs":      append([]string{"rerun", "open-log", "cancel-job", "close-jobs", "quit", "force-quit"}, browse...),
+}
+
+const (
+	PresetVim   = "vim"
+	PresetEmacs = "emacs"
+)
+
+// presets holds the changes of each preset to the default, vim-ish bindings.
+var presets = map[string]map[string][]string{
+	PresetVim: {},
+	PresetEmacs: {
+		"up":            {"ctrl+p", "up"},
+		"down":          {"ctrl+n", "down"},
+		"left":          {"ctrl+b", "left"},
+		"right":         {"ctrl+f", "right"},
+		"page-up":       {"alt+v", "pgup"},
+		"page-down":     {"ctrl+v", "pgdown"},
+		"home":          {"alt+<", "home"},
+		"end":           {"alt+>", "end"},
+		"filter":        {"ctrl+s"},
+		"copy":          {"alt+w"},
+		"quit":          {"ctrl+x", "q"},
+		"close-inspect": {"ctrl+g", "esc", "?"},
+		"close-history": {"ctrl+g", "esc", "h"},
+		"close-deps":    {"ctrl+g", "esc", "d"},
+		"close-system":  {"ctrl+g", "esc", "s"},
+		"close-args":    {"ctrl+g", "esc"},
+		"dismiss-error": {"ctrl+g", "esc"},
+		"refresh":       {"g"},
+		"close-profile": {"ctrl+g", "esc", "p"},
+		"close-output":  {"ctrl+g", "esc"},
+		"close-jobs":    {"ctrl+g", "esc", "J"},
+	},
+}
+
+// Presets are the names of the available presets.
+var Presets = []string{PresetVim, PresetEmacs}
+
+// KeymapFile is the format of the keymap file: a preset, by default 'vim',
+// and bindings overriding it.
+type KeymapFile struct {
+	Preset   string              `toml:"preset"`
+	Bindings map[string][]string `toml:"bindings"`
+}
+
+// Load applies the keymap file at path, if it exists, followed by the
+// overrides, and returns the keys bound twice within a focus, for a warning.
+// It fails on unknown bindings and keys only.
+func Load(path string, overrides map[string][]string) ([]Conflict, error) {
+	var f KeymapFile
+	md, err := toml.DecodeFile(path, &f)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("while reading %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("while reading %s: unknown setting '%s'", path, undecoded[0])
+	}
+	if f.Preset == "" {
+		f.Preset = PresetVim
+	}
+	preset, ok := presets[f.Preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap preset '%s' (%s), must be one of %v", f.Preset, path, Presets)
+	}
+	for _, keymap := range []map[string][]string{preset, f.Bindings, overrides} {
+		if err := Apply(keymap); err != nil {
+			return nil, err
+		}
+	}
+	return Conflicts(Current()), nil
+}
+
+// Apply rebinds the named bindings to the given keys. It must be called
+// before any key map is created.
+func Apply(keymap map[string][]string) error {
+	for name, keys := range keymap {
+		b, ok := bindings[name]
+		if !ok {
+			return fmt.Errorf("unknown key binding '%s' in keymap", name)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("no keys for key binding '%s' in keymap", name)
+		}
+		for _, k := range keys {
+			if !validKey(k) {
+				return fmt.Errorf("unknown key '%s' for key binding '%s' in keymap", k, name)
+			}
+		}
+		b.SetKeys(keys...)
+		if b.Help().Key != "" {
+			b.SetHelp(keys[0], b.Help().Desc)
+		}
+	}
+	return nil
+}
+
+// keyNames are the names of the keys that don't type a character, as
+// bubbletea renders them.
+var keyNames = func() map[string]bool {
+	names := map[string]bool{}
+	for k := tea.KeyF20; k <= tea.KeyBackspace; k++ {
+		if k != tea.KeyRunes && k.String() != "" {
+			names[k.String()] = true
+		}
+	}
+	return names
+}()
+
+// validKey reports whether k names a key, optionally with the alt modifier.
+func validKey(k string) bool {
+	k = strings.TrimPrefix(k, "alt+")
+	return utf8.RuneCountInString(k) == 1 || keyNames[k]
+}
+
+// Current returns the keys of every named binding.
+func Current() map[string][]string {
+	keymap := map[string][]string{}
+	for name, b := range bindings {
+		keymap[name] = b.Keys()
+	}
+	return keymap
+}
+
+// Conflict is a key bound to several bindings that are active together.
+type Conflict struct {
+	Context  string
+	Key      string
+	Bindings []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("in %s: '%s' is bound to %s", c.Context, c.Key, strings.Join(c.Bindings, ", "))
+}
+
+// Conflicts finds the keys of the keymap that are bound to more than one
+// binding in any of the TUI's focus contexts.
+func Conflicts(keymap map[string][]string) []Conflict {
+	var conflicts []Conflict
+	for context, names := range contexts {
+		bound := map[string][]string{}
+		for _, name := range names {
+			for _, k := range keymap[name] {
+				if !yieldsKey(keymap, names, name, k) {
+					bound[k] = append(bound[k], name)
+				}
+			}
+		}
+		for k, names := range bound {
+			if len(names) > 1 {
+				sort.Strings(names)
+				conflicts = append(conflicts, Conflict{context, k, names})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Context != conflicts[j].Context {
+			return conflicts[i].Context < conflicts[j].Context
+		}
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return conflicts
+}
+
+// yieldsKey reports whether the binding gives up the key to another one
+// of the active bindings.
+func yieldsKey(keymap map[string][]string, active []string, name, k string) bool {
+	for _, other := range yields[name] {
+		if contains(active, other) && contains(keymap[other], k) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
 type AppKeyMap struct {
 	ToggleFocus key.Binding
 	FocusLeft   key.Binding
 	FocusRight  key.Binding
 	ShowReadme  key.Binding
+	ShowHistory key.Binding
+	ShowDeps    key.Binding
+	ShowSystems key.Binding
+	Refresh     key.Binding
+	ShowProfile key.Binding
+	ShowJobs    key.Binding
+	Mark        key.Binding
+	ClearMarks  key.Binding
+	ToggleTree  key.Binding
+	ToggleNode  key.Binding
+	ExpandAll   key.Binding
+	CollapseAll key.Binding
 	Quit        key.Binding
 	ForceQuit   key.Binding
 }
@@ -44,6 +349,18 @@ func NewAppKeyMap() *AppKeyMap {
 		FocusLeft:   cursorLeft,
 		FocusRight:  cursorRight,
 		ShowReadme:  showReadme,
+		ShowHistory: showHistory,
+		ShowDeps:    showDeps,
+		ShowSystems: showSystems,
+		Refresh:     refresh,
+		ShowProfile: showProfile,
+		ShowJobs:    showJobs,
+		Mark:        mark,
+		ClearMarks:  clearMarks,
+		ToggleTree:  toggleTree,
+		ToggleNode:  toggleNode,
+		ExpandAll:   expandAll,
+		CollapseAll: collapseAll,
 		ForceQuit:   forceQuit,
 		Quit:        quit,
 	}
@@ -71,6 +388,124 @@ func NewReadmeKeyMap() *ReadmeKeyMap {
 	return m
 }
 
+type HistoryKeyMap struct {
+	Rerun        key.Binding
+	CloseHistory key.Binding
+}
+
+func NewHistoryKeyMap() *HistoryKeyMap {
+	return &HistoryKeyMap{
+		Rerun:        rerun,
+		CloseHistory: closeHistory,
+	}
+}
+
+type DepsKeyMap struct {
+	JumpTo    key.Binding
+	CloseDeps key.Binding
+}
+
+func NewDepsKeyMap() *DepsKeyMap {
+	return &DepsKeyMap{
+		JumpTo:    jumpTo,
+		CloseDeps: closeDeps,
+	}
+}
+
+type ArgsKeyMap struct {
+	RunWithArgs key.Binding
+	CloseArgs   key.Binding
+}
+
+func NewArgsKeyMap() *ArgsKeyMap {
+	return &ArgsKeyMap{
+		RunWithArgs: runWithArgs,
+		CloseArgs:   closeArgs,
+	}
+}
+
+type SystemsKeyMap struct {
+	SelectSystem key.Binding
+	CloseSystems key.Binding
+}
+
+func NewSystemsKeyMap() *SystemsKeyMap {
+	return &SystemsKeyMap{
+		SelectSystem: selectSystem,
+		CloseSystems: closeSystems,
+	}
+}
+
+type ErrorKeyMap struct {
+	viewport.KeyMap
+	Retry   key.Binding
+	Dismiss key.Binding
+	Copy    key.Binding
+}
+
+func NewErrorKeyMap() *ErrorKeyMap {
+	m := &ErrorKeyMap{
+		KeyMap:  ViewportKeyMap(),
+		Retry:   retry,
+		Dismiss: dismissError,
+		Copy:    textcopy,
+	}
+	m.Copy.SetHelp(textcopy.Help().Key, "copy error")
+	return m
+}
+
+type ProfileKeyMap struct {
+	viewport.KeyMap
+	Rerun        key.Binding
+	CloseProfile key.Binding
+}
+
+func NewProfileKeyMap() *ProfileKeyMap {
+	m := &ProfileKeyMap{
+		KeyMap:       ViewportKeyMap(),
+		Rerun:        refresh,
+		CloseProfile: closeProfile,
+	}
+	m.Rerun.SetHelp(refresh.Help().Key, "re-run")
+	return m
+}
+
+type OutputKeyMap struct {
+	viewport.KeyMap
+	CycleTab        key.Binding
+	ReverseCycleTab key.Binding
+	CancelJob       key.Binding
+	CloseOutput     key.Binding
+}
+
+func NewOutputKeyMap() *OutputKeyMap {
+	m := &OutputKeyMap{
+		KeyMap:          ViewportKeyMap(),
+		CycleTab:        cycleTab,
+		ReverseCycleTab: reverseCycleTab,
+		CancelJob:       cancelJob,
+		CloseOutput:     closeOutput,
+	}
+	m.CycleTab.SetHelp(cycleTab.Help().Key, "cycle actions")
+	return m
+}
+
+type JobsKeyMap struct {
+	Rerun     key.Binding
+	OpenLog   key.Binding
+	CancelJob key.Binding
+	CloseJobs key.Binding
+}
+
+func NewJobsKeyMap() *JobsKeyMap {
+	return &JobsKeyMap{
+		Rerun:     rerun,
+		OpenLog:   openLog,
+		CancelJob: cancelJob,
+		CloseJobs: closeJobs,
+	}
+}
+
 // DefaultListKeyMap returns a default set of keybindings.
 func DefaultListKeyMap() list.KeyMap {
 	return list.KeyMap{
@@ -99,8 +534,23 @@ func DefaultListKeyMap() list.KeyMap {
 	}
 }
 
+// TargetListKeyMap returns the list keybindings of the targets, where the
+// page-down binding yields the spacebar to the mark binding.
+func TargetListKeyMap() list.KeyMap {
+	m := DefaultListKeyMap()
+	var keys []string
+	for _, k := range pageDown.Keys() {
+		if !contains(mark.Keys(), k) {
+			keys = append(keys, k)
+		}
+	}
+	m.NextPage.SetKeys(keys...)
+	return m
+}
+
 type ActionDelegateKeyMap struct {
 	Exec        key.Binding
+	EditArgs    key.Binding
 	Copy        key.Binding
 	Inspect     key.Binding
 	QuitInspect key.Binding
@@ -111,6 +561,7 @@ type ActionDelegateKeyMap struct {
 func (d ActionDelegateKeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
 		d.Exec,
+		d.EditArgs,
 		d.Copy,
 		d.Inspect,
 		d.QuitInspect,
@@ -120,6 +571,7 @@ func (d ActionDelegateKeyMap) ShortHelp() []key.Binding {
 func NewActionDelegateKeyMap() *ActionDelegateKeyMap {
 	return &ActionDelegateKeyMap{
 		Exec:        enter,
+		EditArgs:    editArgs,
 		Copy:        textcopy,
 		Inspect:     showReadme,
 		QuitInspect: closeReadme,
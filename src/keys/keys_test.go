@@ -0,0 +1,93 @@
+package keys
+
+import (
+	"testing"
+)
+
+func with(keymap map[string][]string, overrides map[string][]string) map[string][]string {
+	merged := map[string][]string{}
+	for name, keys := range keymap {
+		merged[name] = keys
+	}
+	for name, keys := range overrides {
+		merged[name] = keys
+	}
+	return merged
+}
+
+func TestPresetsHaveNoConflicts(t *testing.T) {
+	for _, name := range Presets {
+		if conflicts := Conflicts(with(Current(), presets[name])); len(conflicts) > 0 {
+			t.Errorf("preset %s has conflicts: %v", name, conflicts)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	conflicts := Conflicts(with(Current(), map[string][]string{"quit": {"c"}}))
+	if len(conflicts) != 3 {
+		t.Fatalf("got %v, want conflicts in actions, error and inspect", conflicts)
+	}
+	for i, context := range []string{"actions", "error", "inspect"} {
+		c := conflicts[i]
+		if c.Context != context || c.Key != "c" || len(c.Bindings) != 2 || c.Bindings[0] != "copy" || c.Bindings[1] != "quit" {
+			t.Errorf("got %v", c)
+		}
+	}
+}
+
+func TestPresetsNameKnownBindings(t *testing.T) {
+	for name, preset := range presets {
+		for binding := range preset {
+			if _, ok := bindings[binding]; !ok {
+				t.Errorf("preset %s rebinds unknown binding %s", name, binding)
+			}
+		}
+	}
+}
+
+func TestTargetListYieldsSpacebar(t *testing.T) {
+	if next := TargetListKeyMap().NextPage.Keys(); len(next) != 1 || next[0] != "pgdown" {
+		t.Errorf("got %v, want the spacebar left to mark", next)
+	}
+	if next := DefaultListKeyMap().NextPage.Keys(); len(next) != 2 || next[1] != spacebar {
+		t.Errorf("got %v, want the spacebar to page down", next)
+	}
+}
+
+func TestPresetsHaveValidKeys(t *testing.T) {
+	for _, keymap := range append([]map[string][]string{Current()}, presets[PresetVim], presets[PresetEmacs]) {
+		for name, keys := range keymap {
+			for _, k := range keys {
+				if !validKey(k) {
+					t.Errorf("%s is bound to unknown key '%s'", name, k)
+				}
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer Apply(Current())
+
+	conflicts, err := Load("missing.toml", map[string][]string{"quit": {"c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 3 {
+		t.Errorf("got %v, want the conflicts reported", conflicts)
+	}
+
+	for _, keymap := range []map[string][]string{
+		{"no-such-binding": {"x"}},
+		{"quit": {}},
+		{"quit": {"ctrl+nope"}},
+	} {
+		if _, err := Load("missing.toml", keymap); err == nil {
+			t.Errorf("%v: got no error", keymap)
+		}
+	}
+	if _, err := Load("missing.toml", map[string][]string{"quit": {"ctrl+q", "alt+q", "f5", spacebar, "ä"}}); err != nil {
+		t.Errorf("got %v for valid keys", err)
+	}
+}
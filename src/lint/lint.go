@@ -0,0 +1,227 @@
+// Package lint finds problems in the repository metadata that don't break
+// loading it, but make for a poor experience in the TUI, CLI or docs.
+package lint
+
+import (
+	"fmt"
+
+	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/graph"
+)
+
+type Severity string
+
+const (
+	Off     Severity = "off"
+	Note    Severity = "note"
+	Warning Severity = "warning"
+	Error   Severity = "error"
+)
+
+// Severities are all severities, from the least to the most severe.
+var Severities = []Severity{Off, Note, Warning, Error}
+
+// Rule is a lint rule with its default severity.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	check       func(r *data.Root, report func(location, message string))
+}
+
+// Finding is a problem found by a rule, at a '//cell', '//cell/block',
+// '//cell/block/target' or '//cell/block/target:action' location.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Location string   `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Schema is the rule of violations of the metadata schema, which are
+// reported by 'data.Validate' rather than by a lint rule.
+const Schema = "schema"
+
+// Rules are all lint rules.
+var Rules = []Rule{
+	{
+		ID:          "missing-description",
+		Description: "targets should have a 'meta.description'",
+		Severity:    Warning,
+		check: func(r *data.Root, report func(string, string)) {
+			eachTarget(r, func(id string, t data.Target) {
+				if t.Descr == nil || *t.Descr == "" {
+					report(id, "target has no 'meta.description'")
+				}
+			})
+		},
+	},
+	{
+		ID:          "missing-readme",
+		Description: "cells, blocks and targets should have a readme",
+		Severity:    Note,
+		check: func(r *data.Root, report func(string, string)) {
+			for _, c := range r.Cells {
+				if c.Readme == nil {
+					report("//"+c.Name, "cell has no readme")
+				}
+				for _, b := range c.Blocks {
+					if b.Readme == nil {
+						report(fmt.Sprintf("//%s/%s", c.Name, b.Name), "block has no readme")
+					}
+				}
+			}
+			eachTarget(r, func(id string, t data.Target) {
+				if t.Readme == nil {
+					report(id, "target has no readme")
+				}
+			})
+		},
+	},
+	{
+		ID:          "unknown-dep",
+		Description: "dependencies should name existing targets",
+		Severity:    Warning,
+		check: func(r *data.Root, report func(string, string)) {
+			g := graph.New(r)
+			missing := g.Missing()
+			for _, id := range g.Nodes {
+				for _, dep := range missing[id] {
+					report(id, fmt.Sprintf("depends on unknown target %s", dep))
+				}
+			}
+		},
+	},
+	{
+		ID:          "empty-action-description",
+		Description: "actions should have a description",
+		Severity:    Warning,
+		check: func(r *data.Root, report func(string, string)) {
+			eachTarget(r, func(id string, t data.Target) {
+				for _, a := range t.Actions {
+					if a.Descr == "" {
+						report(id+":"+a.Name, "action has no description")
+					}
+				}
+			})
+		},
+	},
+	{
+		ID:          "invalid-name",
+		Description: "names must be usable in '//cell/block/target:action' specs",
+		Severity:    Error,
+		check: func(r *data.Root, report func(string, string)) {
+			// every name in turn, with placeholders for the others
+			for _, c := range r.Cells {
+				if !validSpec(c.Name, "b", "t", "a") {
+					report("//"+c.Name, fmt.Sprintf("cell name '%s' breaks the spec", c.Name))
+				}
+				for _, b := range c.Blocks {
+					if !validSpec("c", b.Name, "t", "a") {
+						report(fmt.Sprintf("//%s/%s", c.Name, b.Name), fmt.Sprintf("block name '%s' breaks the spec", b.Name))
+					}
+				}
+			}
+			eachTarget(r, func(id string, t data.Target) {
+				if !validSpec("c", "b", t.Name, "a") {
+					report(id, fmt.Sprintf("target name '%s' breaks the spec", t.Name))
+				}
+				for _, a := range t.Actions {
+					if !validSpec("c", "b", "t", a.Name) {
+						report(id+":"+a.Name, fmt.Sprintf("action name '%s' breaks the spec", a.Name))
+					}
+				}
+			})
+		},
+	},
+}
+
+// validSpec reports whether the CLI parses the spec of the action back into
+// the same names.
+func validSpec(cell, block, target, action string) bool {
+	g, err := data.SpecRe.Groups(fmt.Sprintf("//%s/%s/%s:%s", cell, block, target, action))
+	return err == nil && g["cell"] == cell && g["block"] == block && g["target"] == target && g["action"] == action
+}
+
+func eachTarget(r *data.Root, f func(id string, t data.Target)) {
+	for ci, c := range r.Cells {
+		for bi, b := range c.Blocks {
+			for ti, t := range b.Targets {
+				f(r.TargetTitle(ci, bi, ti), t)
+			}
+		}
+	}
+}
+
+// ResolveSeverities resolves the severity of every rule from the overrides, which
+// map rule ids to severities.
+func ResolveSeverities(overrides map[string]string) (map[string]Severity, error) {
+	severities := map[string]Severity{}
+	for _, rule := range Rules {
+		severities[rule.ID] = rule.Severity
+	}
+	for id, s := range overrides {
+		if _, ok := severities[id]; !ok {
+			return nil, fmt.Errorf("unknown lint rule '%s', must be one of %v", id, RuleIDs())
+		}
+		severity, err := ParseSeverity(s)
+		if err != nil {
+			return nil, fmt.Errorf("lint rule '%s': %w", id, err)
+		}
+		severities[id] = severity
+	}
+	return severities, nil
+}
+
+// ParseSeverity validates a severity.
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range Severities {
+		if Severity(s) == severity {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("invalid severity '%s', must be one of %v", s, Severities)
+}
+
+// RuleIDs returns the ids of all rules.
+func RuleIDs() []string {
+	ids := make([]string, len(Rules))
+	for i, rule := range Rules {
+		ids[i] = rule.ID
+	}
+	return ids
+}
+
+// Run checks the metadata with all rules that aren't off, ordered by rule
+// and then by location in the metadata.
+func Run(r *data.Root, severities map[string]Severity) []Finding {
+	var findings []Finding
+	for _, rule := range Rules {
+		severity := severities[rule.ID]
+		if severity == Off || severity == "" {
+			continue
+		}
+		rule.check(r, func(location, message string) {
+			findings = append(findings, Finding{rule.ID, severity, location, message})
+		})
+	}
+	return findings
+}
+
+// Count returns the number of findings of each severity.
+func Count(findings []Finding) map[Severity]int {
+	count := map[Severity]int{}
+	for _, f := range findings {
+		count[f.Severity]++
+	}
+	return count
+}
+
+// SchemaFindings turns violations of the metadata schema into findings.
+func SchemaFindings(violations []data.Violation) []Finding {
+	findings := make([]Finding, len(violations))
+	for i, v := range violations {
+		findings[i] = Finding{Schema, Error, v.Path, v.Message}
+	}
+	return findings
+}
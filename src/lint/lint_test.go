@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+func TestRun(t *testing.T) {
+	readme, descr := "Readme.md", "the api"
+	root := &data.Root{Cells: []data.Cell{
+		{Name: "backend", Readme: &readme, Blocks: []data.Block{
+			{Name: "apps", Readme: &readme, Targets: []data.Target{
+				{Name: "api", Readme: &readme, Descr: &descr, Deps: []string{"//backend/apps/nowhere"}, Actions: []data.Action{
+					{Name: "build", Descr: "build it"},
+					{Name: "run:it", Descr: ""},
+				}},
+				// the CLI takes ':' in target names
+				{Name: "api:v2", Readme: &readme, Descr: &descr, Actions: []data.Action{
+					{Name: "build", Descr: "build it"},
+				}},
+			}},
+		}},
+	}}
+	severities, err := ResolveSeverities(map[string]string{"unknown-dep": "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{
+		{"unknown-dep", Error, "//backend/apps/api", "depends on unknown target //backend/apps/nowhere"},
+		{"empty-action-description", Warning, "//backend/apps/api:run:it", "action has no description"},
+		{"invalid-name", Error, "//backend/apps/api:run:it", "action name 'run:it' breaks the spec"},
+	}
+	if got := Run(root, severities); !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestResolveSeverities(t *testing.T) {
+	for _, overrides := range []map[string]string{
+		{"no-such-rule": "error"},
+		{"missing-readme": "fatal"},
+	} {
+		if _, err := ResolveSeverities(overrides); err == nil {
+			t.Errorf("%v: got no error", overrides)
+		}
+	}
+	severities, _ := ResolveSeverities(map[string]string{"missing-readme": "off"})
+	if severities["missing-readme"] != Off || severities["invalid-name"] != Error {
+		t.Errorf("got %v", severities)
+	}
+}
@@ -0,0 +1,129 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteText writes one finding per line, followed by a summary.
+func WriteText(w io.Writer, findings []Finding) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	for _, f := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t(%s)\n", f.Severity, f.Location, f.Message, f.Rule)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		count := Count(findings)
+		_, err := fmt.Fprintf(w, "%d error(s), %d warning(s), %d note(s)\n", count[Error], count[Warning], count[Note])
+		return err
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a json array.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log, for code scanning
+// tools. Locations are logical, i.e. target specs or JSON paths.
+func WriteSARIF(w io.Writer, findings []Finding, tool, version string) error {
+	driver := sarifDriver{
+		Name:           tool,
+		Version:        version,
+		InformationURI: "https://github.com/paisano-nix/paisano",
+	}
+	rule := func(id, description string, severity Severity) sarifRule {
+		r := sarifRule{ID: id, ShortDescription: sarifMessage{description}}
+		r.DefaultConfiguration.Level = sarifLevel(severity)
+		return r
+	}
+	driver.Rules = append(driver.Rules, rule(Schema, "the metadata must conform to its schema", Error))
+	for _, r := range Rules {
+		driver.Rules = append(driver.Rules, rule(r.ID, r.Description, r.Severity))
+	}
+	results := []sarifResult{}
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{f.Message},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{f.Location}},
+			}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Tool: sarifTool{driver}, Results: results}},
+	})
+}
+
+// sarifLevel maps a severity to a SARIF level, which calls 'off' 'none'.
+func sarifLevel(s Severity) string {
+	if s == Off {
+		return "none"
+	}
+	return string(s)
+}
@@ -7,32 +7,103 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/TylerBrock/colorjson"
-
+	"github.com/paisano-nix/paisano/cache"
 	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/flake"
 )
 
+// LoadJson decodes the metadata leniently, see data.Parse.
 func LoadJson(r io.Reader) (*data.Root, error) {
-	var root = &data.Root{}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := data.Parse(b)
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		return nil, fmt.Errorf("json syntax error: %w, near: %s", err, excerpt(b, serr.Offset))
+	}
+	return root, err
+}
 
-	var r2 bytes.Buffer
-	r1 := io.TeeReader(r, &r2)
+// excerpt returns the json around the offset on a single line.
+func excerpt(b []byte, offset int64) string {
+	const context = 40
+	from, to := offset-context, offset+context
+	if from < 0 {
+		from = 0
+	}
+	if to > int64(len(b)) {
+		to = int64(len(b))
+	}
+	if from > to {
+		from = to
+	}
+	return fmt.Sprintf("%q", b[from:to])
+}
 
-	var dec = json.NewDecoder(r1)
+// getMetadata returns the cached metadata, if it can be cached at all.
+func getMetadata(c *cache.Cache, key *cache.ActionID) ([]byte, error) {
+	if key == nil {
+		return nil, errNoCache
+	}
+	b, _, err := c.GetBytes(*key)
+	return b, err
+}
 
-	if err := dec.Decode(&root.Cells); err != nil {
-		var serr *json.SyntaxError
-		if errors.As(err, &serr) {
-			return nil, fmt.Errorf("json syntax error: %w: string:\n%v", err, r2.String())
-		}
-		var obj interface{}
-		var debugDecoder = json.NewDecoder(&r2)
-		debugDecoder.Decode(&obj)
-		f := colorjson.NewFormatter()
-		f.Indent = 2
-		s, _ := f.Marshal(obj)
-		return nil, fmt.Errorf("%w - object: %s", err, s)
+// putMetadata caches the metadata of an evaluation, if it can be cached at
+// all, which is also when the entries that weren't used for a while are
+// removed, at most once a day.
+func putMetadata(c *cache.Cache, key *cache.ActionID, b []byte) {
+	if key == nil {
+		return
 	}
+	c.PutBytes(*key, b)
+	c.Trim()
+}
+
+var errNoCache = errors.New("no cache")
+
+// LoadCachedRoot returns the repository metadata from the CLI cache only,
+// without ever evaluating the flake.
+func LoadCachedRoot() (*data.Root, error) {
+	cache, key, _, _, err := flake.LoadFlakeCmd()
+	if err != nil {
+		return nil, err
+	}
+	cached, err := getMetadata(cache, key)
+	if err != nil {
+		return nil, errNoCache
+	}
+	return LoadJson(bytes.NewReader(cached))
+}
 
+// LoadRoot returns the repository metadata, preferring the CLI cache and
+// evaluating the flake (and populating the cache) only on a cache miss.
+func LoadRoot() (*data.Root, error) {
+	cache, key, loadCmd, buf, err := flake.LoadFlakeCmd()
+	if err != nil {
+		return nil, fmt.Errorf("while loading flake (cmd '%v'): %w", loadCmd, err)
+	}
+	cached, err := getMetadata(cache, key)
+	if err == nil {
+		root, err := LoadJson(bytes.NewReader(cached))
+		if err != nil {
+			return nil, fmt.Errorf("while loading cached json: %w", err)
+		}
+		return root, nil
+	}
+	stderr := new(bytes.Buffer)
+	loadCmd.Stderr = stderr
+	if err := loadCmd.Run(); err != nil {
+		return nil, flake.AsEvalError(err, stderr.Bytes())
+	}
+	bufA := &bytes.Buffer{}
+	r := io.TeeReader(buf, bufA)
+	root, err := LoadJson(r)
+	if err != nil {
+		return nil, fmt.Errorf("while loading json (cmd: '%v'): %w", loadCmd, err)
+	}
+	putMetadata(cache, key, bufA.Bytes())
 	return root, nil
 }
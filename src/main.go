@@ -1,10 +1,21 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/config"
+	"github.com/paisano-nix/paisano/env"
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/history"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+	"github.com/paisano-nix/paisano/watch"
 )
 
 var (
@@ -15,22 +26,74 @@ var (
 )
 
 func main() {
+	env.SetEnv() // PRJ_* + NIX_CONFIG
 	if len(os.Args[1:]) == 0 {
 		// with NO arguments, invoke the TUI
-		if model, err := tea.NewProgram(
-			InitialPage(),
-			tea.WithAltScreen(),
-		).StartReturningModel(); err != nil {
-			log.Fatalf("Error running program: %s", err)
-		} else if err := model.(*Tui).FatalError; err != nil {
+		if err := loadConfig(rootCmd, nil); err != nil {
 			log.Fatal(err)
-		} else if command := model.(*Tui).ExecveCommand; command != nil {
-			if err := command.Exec(nil); err != nil {
-				log.Fatal(err)
-			}
 		}
+		runTui()
 	} else {
 		// with arguments, invoke the CLI
 		ExecuteCli()
 	}
 }
+
+// runTui runs the TUI and then the action chosen in it, if any.
+func runTui() {
+	cfg := config.Get()
+	conflicts, err := keys.Load(cfg.KeymapFile, cfg.Keymap)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "warning: conflicting key binding %s\n", c)
+	}
+	theme, err := styles.Load(tuiTheme)
+	if err != nil {
+		log.Fatal(err)
+	}
+	styles.Apply(theme)
+	page := InitialPage()
+	page.StayOpen = tuiStayOpen
+	if tuiWatch {
+		sources, err := flake.Sources()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if page.Watcher, err = watch.New(watch.DefaultDebounce, sources...); err != nil {
+			log.Fatal(err)
+		}
+	}
+	p := tea.NewProgram(
+		page,
+		tea.WithAltScreen(),
+	)
+	// quit on the signals that bubbletea leaves alone, so that the jobs
+	// get shut down and the terminal restored
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	go func() {
+		if _, ok := <-sigs; ok {
+			p.Quit()
+		}
+	}()
+	model, err := p.StartReturningModel()
+	signal.Stop(sigs)
+	close(sigs)
+	if page.Watcher != nil {
+		page.Watcher.Close()
+	}
+	page.JobManager.Shutdown()
+	if err != nil {
+		log.Fatalf("Error running program: %s", err)
+	} else if err := model.(*Tui).FatalError; err != nil {
+		log.Fatal(errorReport(err))
+	} else if command := model.(*Tui).ExecveCommand; command != nil {
+		code, err := runAction(command, model.(*Tui).ExecveArgs, history.SourceTui)
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kballard/go-shellquote"
+
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+type ArgsModel struct {
+	Input  textinput.Model
+	KeyMap *keys.ArgsKeyMap
+	// Cmd is the action the arguments are prompted for.
+	Cmd *flake.RunActionCmd
+	Err error
+}
+
+func NewArgs() *ArgsModel {
+	ti := textinput.New()
+	ti.Prompt = "❯ "
+	ti.Placeholder = "arguments, quoted like in a shell"
+	return &ArgsModel{
+		Input:  ti,
+		KeyMap: keys.NewArgsKeyMap(),
+	}
+}
+
+// Prompt asks for the arguments of the action, starting out with value.
+func (m *ArgsModel) Prompt(cmd *flake.RunActionCmd, value string) tea.Cmd {
+	m.Cmd = cmd
+	m.Err = nil
+	m.Input.SetValue(value)
+	m.Input.CursorEnd()
+	return m.Input.Focus()
+}
+
+// Args splits the input into arguments, honoring shell quoting.
+func (m *ArgsModel) Args() ([]string, error) {
+	args, err := shellquote.Split(m.Input.Value())
+	m.Err = err
+	return args, err
+}
+
+func (m *ArgsModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *ArgsModel) Update(msg tea.Msg) (*ArgsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.Input, cmd = m.Input.Update(msg)
+	return m, cmd
+}
+
+func (m *ArgsModel) View() string {
+	view := fmt.Sprintf("Arguments for %s\n\n%s", m.Cmd.Spec(), m.Input.View())
+	if m.Err != nil {
+		view += "\n\n" + styles.ErrorStyle.Render(m.Err.Error())
+	}
+	return view
+}
+
+func (m *ArgsModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.KeyMap.RunWithArgs,
+		m.KeyMap.CloseArgs,
+	}
+}
+
+func (m *ArgsModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
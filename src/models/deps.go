@@ -0,0 +1,102 @@
+package models
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/graph"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+type DepItem struct {
+	Id        string
+	Dependent bool
+	Known     bool
+}
+
+func (i DepItem) Title() string { return i.Id }
+func (i DepItem) Description() string {
+	desc := "⬇ dependency"
+	if i.Dependent {
+		desc = "⬆ dependent"
+	}
+	if !i.Known {
+		desc += " · 🥺 unknown target"
+	}
+	return desc
+}
+func (i DepItem) FilterValue() string { return i.Title() }
+
+type DepsModel struct {
+	List   list.Model
+	KeyMap *keys.DepsKeyMap
+}
+
+func NewDeps() *DepsModel {
+	l := list.New([]list.Item{}, styles.NewDelegate(), 0, 0)
+	styles.StyleList(&l)
+	l.KeyMap = keys.DefaultListKeyMap()
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("relation", "relations")
+	l.DisableQuitKeybindings()
+	return &DepsModel{
+		List:   l,
+		KeyMap: keys.NewDepsKeyMap(),
+	}
+}
+
+// LoadDeps lists the direct dependencies and dependents of the target.
+func (m *DepsModel) LoadDeps(g *graph.Graph, id string) tea.Cmd {
+	var items []list.Item
+	for _, d := range g.Deps(id) {
+		items = append(items, &DepItem{d, false, g.Has(d)})
+	}
+	for _, d := range g.Dependents(id) {
+		items = append(items, &DepItem{d, true, g.Has(d)})
+	}
+	m.List.Title = "Dependencies of " + id
+	m.List.ResetSelected()
+	return m.List.SetItems(items)
+}
+
+// Selected returns the highlighted relation, if any.
+func (m *DepsModel) Selected() *DepItem {
+	if i, ok := m.List.SelectedItem().(*DepItem); ok {
+		return i
+	}
+	return nil
+}
+
+func (m *DepsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *DepsModel) Update(msg tea.Msg) (*DepsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.List, cmd = m.List.Update(msg)
+	return m, cmd
+}
+
+func (m *DepsModel) View() string {
+	if len(m.List.Items()) == 0 {
+		return m.List.Title + "\n\nThis target has neither dependencies nor dependents."
+	}
+	return m.List.View()
+}
+
+func (m *DepsModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.List.KeyMap.CursorUp,
+		m.List.KeyMap.CursorDown,
+		m.KeyMap.JumpTo,
+		m.KeyMap.CloseDeps,
+	}
+}
+
+func (m *DepsModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
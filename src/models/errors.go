@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+// ErrorModel shows an error of loading the flake, in detail if it's an
+// evaluation error.
+type ErrorModel struct {
+	Viewport viewport.Model
+	KeyMap   *keys.ErrorKeyMap
+	Err      error
+}
+
+func NewError() *ErrorModel {
+	km := keys.NewErrorKeyMap()
+	vp := viewport.New(0, 0)
+	vp.KeyMap = km.KeyMap
+	return &ErrorModel{
+		Viewport: vp,
+		KeyMap:   km,
+	}
+}
+
+// Show displays the error from its top.
+func (m *ErrorModel) Show(err error) {
+	m.Err = err
+	m.Viewport.SetContent(m.render())
+	m.Viewport.GotoTop()
+}
+
+func (m *ErrorModel) SetSize(width, height int) {
+	m.Viewport.Width = width
+	m.Viewport.Height = height
+	if m.Err != nil {
+		m.Viewport.SetContent(m.render())
+	}
+}
+
+// Text returns the error as plain text, e.g. for copying.
+func (m *ErrorModel) Text() string {
+	var evalErr *flake.EvalError
+	if errors.As(m.Err, &evalErr) {
+		return evalErr.Report()
+	}
+	return m.Err.Error()
+}
+
+func (m *ErrorModel) render() string {
+	wrap := lipgloss.NewStyle().Width(m.Viewport.Width)
+	var evalErr *flake.EvalError
+	if !errors.As(m.Err, &evalErr) {
+		return wrap.Render(styles.ErrorMessageStyle.Render(m.Err.Error()))
+	}
+	var b strings.Builder
+	b.WriteString(wrap.Render(styles.ErrorMessageStyle.Render(evalErr.Message)))
+	b.WriteString("\n")
+	if loc := evalErr.Location(); loc != "" {
+		fmt.Fprintf(&b, "\nat %s", loc)
+	}
+	if evalErr.AttrPath != "" {
+		fmt.Fprintf(&b, "\nwhile evaluating '%s'", evalErr.AttrPath)
+	}
+	if len(evalErr.Snippet) > 0 {
+		fmt.Fprintf(&b, "\n\n%s", strings.Join(evalErr.Snippet, "\n"))
+	}
+	if len(evalErr.Trace) > 0 {
+		trace := "trace:"
+		for _, frame := range evalErr.Trace {
+			trace += "\n  " + frame
+		}
+		fmt.Fprintf(&b, "\n\n%s", wrap.Copy().Faint(true).Render(trace))
+	}
+	return b.String()
+}
+
+func (m *ErrorModel) Update(msg tea.Msg) (*ErrorModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.Viewport, cmd = m.Viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *ErrorModel) View() string {
+	return m.Viewport.View()
+}
+
+// ShortHelp offers dismissing the error only if there is something to go
+// back to.
+func (m *ErrorModel) ShortHelp(dismissable bool) []key.Binding {
+	kb := []key.Binding{
+		m.KeyMap.Up,
+		m.KeyMap.Down,
+		m.KeyMap.Retry,
+		m.KeyMap.Copy,
+	}
+	if dismissable {
+		kb = append(kb, m.KeyMap.Dismiss)
+	}
+	return kb
+}
+
+func (m *ErrorModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/history"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+type HistoryItem struct {
+	history.Entry
+}
+
+func (i HistoryItem) Title() string {
+	return strings.Join(append([]string{i.Spec}, i.Args...), " ")
+}
+func (i HistoryItem) Description() string {
+	status := "✓ succeeded"
+	if i.ExitCode != 0 {
+		status = fmt.Sprintf("✗ exit code %d", i.ExitCode)
+	}
+	desc := fmt.Sprintf("%s · %s · took %s", i.Start.Local().Format(time.Stamp), status, i.Duration().Round(time.Millisecond))
+	if i.System != "" {
+		desc += " · for " + i.System
+	}
+	return desc
+}
+func (i HistoryItem) FilterValue() string { return i.Title() }
+
+type HistoryModel struct {
+	List   list.Model
+	KeyMap *keys.HistoryKeyMap
+	Err    error
+}
+
+func NewHistory() *HistoryModel {
+	l := list.New([]list.Item{}, styles.NewDelegate(), 0, 0)
+	styles.StyleList(&l)
+	l.Title = "History"
+	l.KeyMap = keys.DefaultListKeyMap()
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.DisableQuitKeybindings()
+	return &HistoryModel{
+		List:   l,
+		KeyMap: keys.NewHistoryKeyMap(),
+	}
+}
+
+// LoadHistory (re-)reads the history log, most recent entries first.
+func (m *HistoryModel) LoadHistory() tea.Cmd {
+	entries, err := history.Load()
+	m.Err = err
+	items := make([]list.Item, len(entries))
+	for i, e := range entries {
+		items[i] = &HistoryItem{e}
+	}
+	m.List.ResetSelected()
+	return m.List.SetItems(items)
+}
+
+// Selected returns the highlighted entry, if any.
+func (m *HistoryModel) Selected() *history.Entry {
+	if i, ok := m.List.SelectedItem().(*HistoryItem); ok {
+		return &i.Entry
+	}
+	return nil
+}
+
+func (m *HistoryModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HistoryModel) Update(msg tea.Msg) (*HistoryModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.List, cmd = m.List.Update(msg)
+	return m, cmd
+}
+
+func (m *HistoryModel) View() string {
+	if m.Err != nil {
+		return fmt.Sprintf("Could not load the history: %v", m.Err)
+	}
+	if len(m.List.Items()) == 0 {
+		return "No actions have been run yet."
+	}
+	return m.List.View()
+}
+
+func (m *HistoryModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.List.KeyMap.CursorUp,
+		m.List.KeyMap.CursorDown,
+		m.KeyMap.Rerun,
+		m.KeyMap.CloseHistory,
+	}
+}
+
+func (m *HistoryModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
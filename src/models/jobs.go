@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/jobs"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+type JobItem struct {
+	*jobs.Job
+}
+
+func (i JobItem) Title() string { return i.Spec() }
+func (i JobItem) Description() string {
+	icon, status := jobStatus(i.Job, "…")
+	return fmt.Sprintf("#%d · %s %s · started %s", i.ID, icon, status, i.Start.Format(time.Kitchen))
+}
+func (i JobItem) FilterValue() string { return i.Title() }
+
+// JobsTickMsg updates the elapsed time of running jobs.
+type JobsTickMsg struct{}
+
+// JobsModel lists the jobs of the session, most recent first.
+type JobsModel struct {
+	List    list.Model
+	KeyMap  *keys.JobsKeyMap
+	Manager *jobs.Manager
+	ticking bool
+}
+
+func NewJobs(manager *jobs.Manager) *JobsModel {
+	l := list.New([]list.Item{}, styles.NewDelegate(), 0, 0)
+	styles.StyleList(&l)
+	l.Title = "Jobs"
+	l.KeyMap = keys.DefaultListKeyMap()
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.DisableQuitKeybindings()
+	return &JobsModel{
+		List:    l,
+		KeyMap:  keys.NewJobsKeyMap(),
+		Manager: manager,
+	}
+}
+
+// Refresh lists the jobs anew, keeping the selected one highlighted, and
+// keeps updating them while any is running.
+func (m *JobsModel) Refresh() tea.Cmd {
+	selected := m.Selected()
+	all := m.Manager.Jobs()
+	items := make([]list.Item, len(all))
+	index := 0
+	for i, j := range all {
+		items[i] = JobItem{j}
+		if j == selected {
+			index = i
+		}
+	}
+	cmd := m.List.SetItems(items)
+	m.List.Select(index)
+	if m.ticking || m.Manager.Running() == 0 {
+		return cmd
+	}
+	m.ticking = true
+	return tea.Batch(cmd, tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return JobsTickMsg{}
+	}))
+}
+
+// Selected returns the highlighted job, if any.
+func (m *JobsModel) Selected() *jobs.Job {
+	if i, ok := m.List.SelectedItem().(JobItem); ok {
+		return i.Job
+	}
+	return nil
+}
+
+func (m *JobsModel) Update(msg tea.Msg) (*JobsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.(type) {
+	case JobsTickMsg:
+		m.ticking = false
+		return m, m.Refresh()
+	case jobs.StartedMsg, jobs.FinishedMsg:
+		return m, m.Refresh()
+	}
+	m.List, cmd = m.List.Update(msg)
+	return m, cmd
+}
+
+func (m *JobsModel) View() string {
+	if len(m.List.Items()) == 0 {
+		return "No actions have been run in this session yet."
+	}
+	return m.List.View()
+}
+
+// ShortHelp offers cancelling if the highlighted job is running.
+func (m *JobsModel) ShortHelp() []key.Binding {
+	kb := []key.Binding{
+		m.List.KeyMap.CursorUp,
+		m.List.KeyMap.CursorDown,
+		m.KeyMap.Rerun,
+		m.KeyMap.OpenLog,
+	}
+	if j := m.Selected(); j != nil && j.State() == jobs.Running {
+		kb = append(kb, m.KeyMap.CancelJob)
+	}
+	return append(kb, m.KeyMap.CloseJobs)
+}
+
+func (m *JobsModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
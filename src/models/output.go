@@ -0,0 +1,314 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/jobs"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+// RunStep is one action of a run. Its Job is nil until it's started, and it
+// is Skipped if an earlier action was cancelled.
+type RunStep struct {
+	Cmd     flake.RunActionCmd
+	Job     *jobs.Job
+	Skipped bool
+}
+
+// Failed reports whether the action didn't run successfully.
+func (s *RunStep) Failed() bool {
+	return s.Skipped || (s.Job != nil && s.Job.State() > jobs.Succeeded)
+}
+
+// finished reports whether the action won't run anymore.
+func (s *RunStep) finished() bool {
+	return s.Skipped || (s.Job != nil && s.Job.State() != jobs.Running)
+}
+
+// OutputModel runs one or more actions as jobs, one after the other, and
+// shows the status of each as well as the output of one of them in a
+// scrollable pane. The jobs keep running when the pane is closed.
+//
+// The actions write to a pipe, not to a terminal, so those that color their
+// output only on a terminal print it plain here.
+type OutputModel struct {
+	Steps    []RunStep
+	Jobs     *jobs.Manager
+	Viewport viewport.Model
+	KeyMap   *keys.OutputKeyMap
+	Spinner  spinner.Model
+	Width    int
+	Height   int
+	// shown is the step whose output is shown; it follows the running
+	// step unless another one was picked.
+	shown  int
+	picked bool
+	args   []string
+}
+
+func NewOutput(manager *jobs.Manager) *OutputModel {
+	km := keys.NewOutputKeyMap()
+	vp := viewport.New(0, 0)
+	vp.KeyMap = km.KeyMap
+	spin := spinner.New()
+	spin.Spinner = spinner.Points
+	return &OutputModel{
+		Jobs:     manager,
+		Viewport: vp,
+		KeyMap:   km,
+		Spinner:  spin,
+	}
+}
+
+// Start runs the commands in order, each with args.
+func (m *OutputModel) Start(cmds []flake.RunActionCmd, args []string) tea.Cmd {
+	m.Steps = make([]RunStep, len(cmds))
+	for i, c := range cmds {
+		m.Steps[i] = RunStep{Cmd: c}
+	}
+	m.args = args
+	m.shown, m.picked = 0, false
+	m.startStep(0)
+	m.SetSize(m.Width, m.Height)
+	return m.Spinner.Tick
+}
+
+// Open shows a job that was started before, e.g. from the jobs panel.
+func (m *OutputModel) Open(j *jobs.Job) tea.Cmd {
+	m.Steps = []RunStep{{Cmd: j.Cmd, Job: j}}
+	m.args = j.Args
+	m.shown, m.picked = 0, false
+	m.SetSize(m.Width, m.Height)
+	m.Viewport.GotoBottom()
+	return m.Spinner.Tick
+}
+
+func (m *OutputModel) startStep(i int) {
+	m.Steps[i].Job = m.Jobs.Start(m.Steps[i].Cmd, m.args)
+	if !m.picked {
+		m.shown = i
+	}
+}
+
+// Running reports whether an action is still running or yet to run.
+func (m *OutputModel) Running() bool {
+	for i := range m.Steps {
+		if !m.Steps[i].finished() {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancel interrupts the running action, which skips the remaining ones.
+func (m *OutputModel) Cancel() {
+	for _, s := range m.Steps {
+		if s.Job != nil {
+			s.Job.Cancel()
+		}
+	}
+}
+
+// SetSize sizes the output pane to what the list of steps leaves.
+func (m *OutputModel) SetSize(width, height int) {
+	m.Width, m.Height = width, height
+	m.Viewport.Width = width
+	// the summary and the heading of the output take two lines each
+	m.Viewport.Height = height - len(m.Steps) - 4
+	if m.Viewport.Height < 1 {
+		m.Viewport.Height = 1
+	}
+	m.refresh()
+}
+
+// refresh shows the latest output, following it if the pane is scrolled to
+// the bottom.
+func (m *OutputModel) refresh() {
+	follow := m.Viewport.AtBottom()
+	m.setContent()
+	if follow {
+		m.Viewport.GotoBottom()
+	}
+}
+
+func (m *OutputModel) setContent() {
+	if len(m.Steps) == 0 {
+		return
+	}
+	var content string
+	if j := m.Steps[m.shown].Job; j != nil {
+		content = strings.Join(j.Log().Lines(), "\n")
+	}
+	m.Viewport.SetContent(lipgloss.NewStyle().Width(m.Viewport.Width).Render(content))
+}
+
+// cycle shows the latest output of the next or previous step.
+func (m *OutputModel) cycle(by int) {
+	n := len(m.Steps)
+	if n == 0 {
+		return
+	}
+	m.shown = (m.shown + by + n) % n
+	m.picked = true
+	m.setContent()
+	m.Viewport.GotoBottom()
+}
+
+func (m *OutputModel) Update(msg tea.Msg) (*OutputModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case jobs.FinishedMsg:
+		// the run goes on when its job finishes, even with the pane closed
+		for i, s := range m.Steps {
+			if s.Job != msg.Job {
+				continue
+			}
+			defer m.refresh()
+			next := i + 1
+			if next == len(m.Steps) {
+				return m, nil
+			}
+			if msg.Job.State() == jobs.Cancelled {
+				for j := next; j < len(m.Steps); j++ {
+					m.Steps[j].Skipped = true
+				}
+				return m, nil
+			}
+			m.startStep(next)
+			return m, nil
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if !m.Running() {
+			return m, nil
+		}
+		m.Spinner, cmd = m.Spinner.Update(msg)
+		m.refresh()
+		return m, cmd
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.KeyMap.CycleTab):
+			m.cycle(1)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.ReverseCycleTab):
+			m.cycle(-1)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.CancelJob):
+			m.Cancel()
+			return m, nil
+		}
+	}
+	m.Viewport, cmd = m.Viewport.Update(msg)
+	return m, cmd
+}
+
+// summary counts the actions by their outcome.
+func (m *OutputModel) summary() string {
+	var done, failed int
+	for i := range m.Steps {
+		if m.Steps[i].finished() {
+			done++
+			if m.Steps[i].Failed() {
+				failed++
+			}
+		}
+	}
+	switch {
+	case len(m.Steps) == 1 && m.Running():
+		return "Running"
+	case len(m.Steps) == 1 && failed > 0:
+		return "Failed"
+	case len(m.Steps) == 1:
+		return "Succeeded"
+	case m.Running():
+		return fmt.Sprintf("Running %d of %d actions", done+1, len(m.Steps))
+	case failed > 0:
+		return fmt.Sprintf("%d of %d actions failed", failed, len(m.Steps))
+	}
+	return fmt.Sprintf("All %d actions succeeded", len(m.Steps))
+}
+
+func (m *OutputModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.summary() + "\n\n")
+	for i, s := range m.Steps {
+		var icon, status string
+		switch {
+		case s.Skipped:
+			icon, status = "-", "skipped"
+		case s.Job == nil:
+			icon = "·"
+		default:
+			icon, status = jobStatus(s.Job, m.Spinner.View())
+		}
+		spec := s.Cmd.Spec()
+		if i == m.shown && len(m.Steps) > 1 {
+			spec = lipgloss.NewStyle().Bold(true).Render(spec)
+		}
+		line := fmt.Sprintf("%s %s", icon, spec)
+		if status != "" {
+			line += "  " + styles.StatusStyle.Copy().MarginLeft(0).Render(status)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.Steps) > 0 {
+		heading := fmt.Sprintf("output of %s", m.Steps[m.shown].Cmd.Spec())
+		if !m.Viewport.AtBottom() {
+			heading += fmt.Sprintf(" (%3.f%%)", m.Viewport.ScrollPercent()*100)
+		}
+		b.WriteString("\n" + lipgloss.NewStyle().Faint(true).Render(heading) + "\n")
+		b.WriteString(m.Viewport.View())
+	}
+	return lipgloss.NewStyle().Width(m.Width).Height(m.Height).MaxHeight(m.Height).Render(b.String())
+}
+
+// jobStatus renders the state of a job as an icon and how long it ran.
+func jobStatus(j *jobs.Job, spinner string) (icon, status string) {
+	elapsed := j.Elapsed().Round(time.Millisecond)
+	if elapsed > time.Minute {
+		elapsed = elapsed.Round(time.Second)
+	}
+	res, err := j.Result()
+	switch j.State() {
+	case jobs.Running:
+		return spinner, fmt.Sprintf("running for %s", elapsed.Round(time.Second))
+	case jobs.Cancelled:
+		return "✗", fmt.Sprintf("cancelled after %s", elapsed)
+	case jobs.Failed:
+		switch {
+		case err != nil:
+			return "✗", err.Error()
+		case res.ExitCode < 0:
+			return "✗", fmt.Sprintf("interrupted after %s", elapsed)
+		}
+		return "✗", fmt.Sprintf("exit code %d after %s", res.ExitCode, elapsed)
+	}
+	return "✓", elapsed.String()
+}
+
+// ShortHelp offers cancelling while an action runs.
+func (m *OutputModel) ShortHelp() []key.Binding {
+	kb := []key.Binding{m.KeyMap.Up, m.KeyMap.Down}
+	if len(m.Steps) > 1 {
+		kb = append(kb, m.KeyMap.CycleTab)
+	}
+	if m.Running() {
+		kb = append(kb, m.KeyMap.CancelJob)
+	}
+	return append(kb, m.KeyMap.CloseOutput)
+}
+
+func (m *OutputModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+// ProfiledMsg carries the outcome of profiling the evaluation.
+type ProfiledMsg struct {
+	Profile *flake.Profile
+	Err     error
+}
+
+// ProfileModel is a debug overlay that times the evaluation of the flake's
+// metadata, step by step and cell by cell.
+type ProfileModel struct {
+	Viewport viewport.Model
+	KeyMap   *keys.ProfileKeyMap
+	Spinner  spinner.Model
+	Profile  *flake.Profile
+	Err      error
+	// LastLoad is how long the TUI last took to load the metadata, and
+	// Cached whether it was a cache hit.
+	LastLoad time.Duration
+	Cached   bool
+	running  bool
+}
+
+func NewProfile() *ProfileModel {
+	km := keys.NewProfileKeyMap()
+	vp := viewport.New(0, 0)
+	vp.KeyMap = km.KeyMap
+	spin := spinner.New()
+	spin.Spinner = spinner.Points
+	return &ProfileModel{
+		Viewport: vp,
+		KeyMap:   km,
+		Spinner:  spin,
+	}
+}
+
+// Run profiles the evaluation in the background, unless it already does.
+func (m *ProfileModel) Run() tea.Cmd {
+	if m.running {
+		return nil
+	}
+	m.running = true
+	m.Viewport.SetContent(m.render())
+	return tea.Batch(func() tea.Msg {
+		p, err := flake.NewProfile(flake.ProfileOptions{Stats: true, CellNames: data.CellNames})
+		return ProfiledMsg{p, err}
+	}, m.Spinner.Tick)
+}
+
+func (m *ProfileModel) SetSize(width, height int) {
+	m.Viewport.Width = width
+	m.Viewport.Height = height
+	m.Viewport.SetContent(m.render())
+}
+
+func (m *ProfileModel) render() string {
+	var s string
+	if m.LastLoad > 0 {
+		s = fmt.Sprintf("Last load took %s.\n\n", m.LastLoad.Round(time.Millisecond))
+	} else if m.Cached {
+		s = "Last load was a cache hit.\n\n"
+	}
+	switch {
+	case m.running:
+		s += "Profiling the evaluation " + m.Spinner.View()
+	case m.Err != nil:
+		s += lipgloss.NewStyle().Width(m.Viewport.Width).Render(
+			styles.ErrorMessageStyle.Render(m.Err.Error()))
+	case m.Profile != nil:
+		s += m.Profile.Report()
+	}
+	return s
+}
+
+func (m *ProfileModel) Update(msg tea.Msg) (*ProfileModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case ProfiledMsg:
+		m.running = false
+		m.Profile, m.Err = msg.Profile, msg.Err
+		m.Viewport.SetContent(m.render())
+		return m, nil
+	case spinner.TickMsg:
+		if !m.running {
+			return m, nil
+		}
+		m.Spinner, cmd = m.Spinner.Update(msg)
+		m.Viewport.SetContent(m.render())
+		return m, cmd
+	}
+	m.Viewport, cmd = m.Viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *ProfileModel) View() string {
+	return m.Viewport.View()
+}
+
+func (m *ProfileModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.KeyMap.Up,
+		m.KeyMap.Down,
+		m.KeyMap.Rerun,
+		m.KeyMap.CloseProfile,
+	}
+}
+
+func (m *ProfileModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -40,7 +41,10 @@ var (
 		BottomLeft:  "┴",
 		BottomRight: "┴",
 	}
+)
 
+// tabStyles returns the styles of the tabs in the current theme.
+func tabStyles() (tab, activeTab, tabGap lipgloss.Style) {
 	tab = lipgloss.NewStyle().
 		Border(tabBorder, true).
 		BorderForeground(styles.Highlight).
@@ -52,7 +56,8 @@ var (
 		BorderTop(false).
 		BorderLeft(false).
 		BorderRight(false)
-)
+	return
+}
 
 type ReadmeModel struct {
 	TargetHelp markdown.Bubble
@@ -68,14 +73,25 @@ type ReadmeModel struct {
 	// Focus
 }
 
-type renderCellMarkdownMsg struct {
-	msg tea.Msg
+type renderMarkdownMsg struct {
+	help    *markdown.Bubble
+	file    string
+	content string
+	err     error
 }
-type renderBlockMarkdownMsg struct {
-	msg tea.Msg
-}
-type renderTargetMarkdownMsg struct {
-	msg tea.Msg
+
+// renderMarkdown reads the readme file and renders it in the style of the
+// current theme.
+func renderMarkdown(help *markdown.Bubble) tea.Cmd {
+	file, width := help.FileName, help.Viewport.Width
+	return func() tea.Msg {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return renderMarkdownMsg{help: help, file: file, err: err}
+		}
+		out, err := styles.RenderMarkdown(width, string(content))
+		return renderMarkdownMsg{help: help, file: file, content: out, err: err}
+	}
 }
 
 func (m *ReadmeModel) LoadReadme(d *data.Root, ci, bi, ti int) {
@@ -100,7 +116,7 @@ func NewReadme() *ReadmeModel {
 		TargetHelp: th,
 		CellHelp:   ch,
 		BlockHelp:  oh,
-		Help:       help.New(),
+		Help:       styles.NewHelp(),
 		KeyMap:     keys.NewReadmeKeyMap(),
 	}
 }
@@ -109,41 +125,28 @@ func (m *ReadmeModel) Init() tea.Cmd {
 }
 
 func (m *ReadmeModel) RenderMarkdown(d *data.Root, ci, bi, ti int) tea.Cmd {
-	var (
-		cmds []tea.Cmd
-		cmd  tea.Cmd
-	)
 	m.LoadReadme(d, ci, bi, ti)
 	m.TargetHelp.SetIsActive(true)
+	m.CellHelp.FileName, m.BlockHelp.FileName, m.TargetHelp.FileName = "", "", ""
 	if d.HasCellHelp(ci, bi, ti) {
-		cmd = func() tea.Msg {
-			return renderCellMarkdownMsg{
-				m.CellHelp.SetFileName(
-					d.CellHelp(ci, bi, ti),
-				)(),
-			}
-		}
-		cmds = append(cmds, cmd)
+		m.CellHelp.FileName = d.CellHelp(ci, bi, ti)
 	}
 	if d.HasBlockHelp(ci, bi, ti) {
-		cmd = func() tea.Msg {
-			return renderBlockMarkdownMsg{
-				m.BlockHelp.SetFileName(
-					d.BlockHelp(ci, bi, ti),
-				)(),
-			}
-		}
-		cmds = append(cmds, cmd)
+		m.BlockHelp.FileName = d.BlockHelp(ci, bi, ti)
 	}
 	if d.HasTargetHelp(ci, bi, ti) {
-		cmd = func() tea.Msg {
-			return renderTargetMarkdownMsg{
-				m.TargetHelp.SetFileName(
-					d.TargetHelp(ci, bi, ti),
-				)(),
-			}
+		m.TargetHelp.FileName = d.TargetHelp(ci, bi, ti)
+	}
+	return m.render()
+}
+
+// render renders all readmes that have a file.
+func (m *ReadmeModel) render() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, help := range []*markdown.Bubble{&m.CellHelp, &m.BlockHelp, &m.TargetHelp} {
+		if help.FileName != "" {
+			cmds = append(cmds, renderMarkdown(help))
 		}
-		cmds = append(cmds, cmd)
 	}
 	return tea.Batch(cmds...)
 }
@@ -181,18 +184,25 @@ func (m *ReadmeModel) Update(msg tea.Msg) (*ReadmeModel, tea.Cmd) {
 			return m, nil
 		}
 	case tea.WindowSizeMsg:
+		// the bubbles' own re-rendering doesn't know the theme
 		m.CellHelp.SetSize(m.Width, m.Height)
 		m.BlockHelp.SetSize(m.Width, m.Height)
 		m.TargetHelp.SetSize(m.Width, m.Height)
-	case renderCellMarkdownMsg:
-		m.CellHelp, cmd = m.CellHelp.Update(msg.msg)
-		return m, cmd
-	case renderBlockMarkdownMsg:
-		m.BlockHelp, cmd = m.BlockHelp.Update(msg.msg)
-		return m, cmd
-	case renderTargetMarkdownMsg:
-		m.TargetHelp, cmd = m.TargetHelp.Update(msg.msg)
-		return m, cmd
+		return m, m.render()
+	case renderMarkdownMsg:
+		if msg.file != msg.help.FileName {
+			// rendered for a previous target
+			return m, nil
+		}
+		if msg.err != nil {
+			msg.help.Viewport.SetContent(msg.err.Error())
+			return m, nil
+		}
+		msg.help.Viewport.SetContent(lipgloss.NewStyle().
+			Width(msg.help.Viewport.Width).
+			Height(msg.help.Viewport.Height).
+			Render(msg.content))
+		return m, nil
 	}
 	if m.TargetHelp.Active {
 		m.TargetHelp, cmd = m.TargetHelp.Update(msg)
@@ -210,6 +220,7 @@ func (m *ReadmeModel) View() string {
 		tabs    []string
 		content string
 	)
+	tab, activeTab, tabGap := tabStyles()
 	if m.CellHelp.Active {
 		tabs = append(tabs, activeTab.Render(fmt.Sprintf("Cell: %s", m.Cell)))
 		content = m.CellHelp.View()
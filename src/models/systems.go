@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/keys"
+	"github.com/paisano-nix/paisano/styles"
+)
+
+type SystemItem struct {
+	Name    string
+	Current bool
+}
+
+func (i SystemItem) Title() string { return i.Name }
+func (i SystemItem) Description() string {
+	if i.Current {
+		return "current system"
+	}
+	return "build for this system"
+}
+func (i SystemItem) FilterValue() string { return i.Title() }
+
+// SystemsLoadedMsg carries the systems known to the flake.
+type SystemsLoadedMsg struct {
+	Current string
+	Systems []string
+	Err     error
+}
+
+type SystemsModel struct {
+	List    list.Model
+	KeyMap  *keys.SystemsKeyMap
+	Current string
+	Err     error
+}
+
+func NewSystems() *SystemsModel {
+	l := list.New([]list.Item{}, styles.NewDelegate(), 0, 0)
+	styles.StyleList(&l)
+	l.Title = "System"
+	l.KeyMap = keys.DefaultListKeyMap()
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("system", "systems")
+	l.DisableQuitKeybindings()
+	return &SystemsModel{
+		List:   l,
+		KeyMap: keys.NewSystemsKeyMap(),
+	}
+}
+
+// LoadSystems asks the flake for its systems, unless they are known already.
+func (m *SystemsModel) LoadSystems() tea.Cmd {
+	if len(m.List.Items()) > 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		current, err := flake.CurrentSystem()
+		if err != nil {
+			return SystemsLoadedMsg{Err: err}
+		}
+		systems, err := flake.Systems()
+		return SystemsLoadedMsg{current, systems, err}
+	}
+}
+
+// Select moves the cursor to the given system, or to the current one if empty.
+func (m *SystemsModel) Select(system string) {
+	for i, item := range m.List.Items() {
+		s := item.(*SystemItem)
+		if s.Name == system || (system == "" && s.Current) {
+			m.List.Select(i)
+			return
+		}
+	}
+}
+
+// Selected returns the highlighted system, if any.
+func (m *SystemsModel) Selected() *SystemItem {
+	if i, ok := m.List.SelectedItem().(*SystemItem); ok {
+		return i
+	}
+	return nil
+}
+
+func (m *SystemsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *SystemsModel) Update(msg tea.Msg) (*SystemsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case SystemsLoadedMsg:
+		m.Err = msg.Err
+		if msg.Err != nil {
+			return m, nil
+		}
+		m.Current = msg.Current
+		// the current system is always available
+		systems := map[string]bool{msg.Current: true}
+		for _, s := range msg.Systems {
+			systems[s] = true
+		}
+		var items []list.Item
+		for s := range systems {
+			items = append(items, &SystemItem{s, s == msg.Current})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(*SystemItem).Name < items[j].(*SystemItem).Name
+		})
+		return m, m.List.SetItems(items)
+	}
+	m.List, cmd = m.List.Update(msg)
+	return m, cmd
+}
+
+func (m *SystemsModel) View() string {
+	if m.Err != nil {
+		return fmt.Sprintf("Could not determine the systems: %v", m.Err)
+	}
+	if len(m.List.Items()) == 0 {
+		return "Asking the flake for its systems ..."
+	}
+	return m.List.View()
+}
+
+func (m *SystemsModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.List.KeyMap.CursorUp,
+		m.List.KeyMap.CursorDown,
+		m.KeyMap.SelectSystem,
+		m.KeyMap.CloseSystems,
+	}
+}
+
+func (m *SystemsModel) FullHelp() [][]key.Binding {
+	kb := [][]key.Binding{{}}
+	return kb
+}
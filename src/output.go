@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/lint"
+)
+
+const (
+	outputTable  = "table"
+	outputJson   = "json"
+	outputYaml   = "yaml"
+	outputNdjson = "ndjson"
+	outputText   = "text"
+	outputSarif  = "sarif"
+)
+
+const (
+	depsTree    = "tree"
+	depsDot     = "dot"
+	depsMermaid = "mermaid"
+)
+
+var (
+	listOutputFormats  = []string{outputTable, outputJson, outputYaml, outputNdjson}
+	depsFormats        = []string{depsTree, depsDot, depsMermaid, outputJson}
+	checkOutputFormats = []string{outputText, outputJson, outputSarif}
+)
+
+type listing struct {
+	Cells []data.CellExport `json:"cells" yaml:"cells"`
+}
+
+func validateOutputFormat(format string, formats []string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format '%s', must be one of %v", format, formats)
+}
+
+func writeList(w io.Writer, format string, root *data.Root) error {
+	switch format {
+	case outputJson:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing{root.Export()})
+	case outputYaml:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(listing{root.Export()}); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputNdjson:
+		enc := json.NewEncoder(w)
+		for _, rec := range root.ActionRecords() {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 5, 2, 4, ' ', 0)
+		for _, c := range root.Cells {
+			for _, o := range c.Blocks {
+				for _, t := range o.Targets {
+					for _, a := range t.Actions {
+						fmt.Fprintf(tw, "//%s/%s/%s:%s\t--\t%s:  %s\n", c.Name, o.Name, t.Name, a.Name, t.Description(), a.Description())
+					}
+				}
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+func writeFindings(w io.Writer, format string, findings []lint.Finding) error {
+	switch format {
+	case outputJson:
+		return lint.WriteJSON(w, findings)
+	case outputSarif:
+		return lint.WriteSARIF(w, findings, argv0, buildVersion)
+	default:
+		return lint.WriteText(w, findings)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func strPtr(s string) *string { return &s }
+
+// exportRoot has targets with and without a description, readme and deps.
+func exportRoot() *data.Root {
+	return &data.Root{Cells: []data.Cell{
+		{Name: "backend", Readme: strPtr("# backend"), Blocks: []data.Block{
+			{Name: "apps", Blocktype: "installables", Readme: strPtr("# apps"), Targets: []data.Target{
+				{Name: "api", Descr: strPtr("the api"), Readme: strPtr("# api"), Deps: []string{"//backend/apps/lib"}, Actions: []data.Action{
+					{Name: "build", Descr: "build it"},
+					{Name: "run", Descr: "run it"},
+				}},
+				{Name: "lib", Actions: []data.Action{{Name: "build", Descr: "build it"}}},
+			}},
+		}},
+		{Name: "docs", Blocks: []data.Block{
+			{Name: "site", Blocktype: "data", Targets: []data.Target{
+				{Name: "empty"},
+			}},
+		}},
+	}}
+}
+
+func TestWriteList(t *testing.T) {
+	for _, format := range []string{outputJson, outputYaml, outputNdjson, outputTable} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeList(&buf, format, exportRoot()); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "list."+format)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
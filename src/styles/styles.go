@@ -1,39 +1,114 @@
 package styles
 
 import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	Highlight = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
-	AppStyle  = lipgloss.NewStyle().Padding(1, 2)
+	Highlight lipgloss.TerminalColor = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
+	AppStyle                         = lipgloss.NewStyle().Padding(1, 2)
+
+	ErrorStyle            lipgloss.Style
+	ErrorMessageStyle     lipgloss.Style
+	TargetStyle           lipgloss.Style
+	ActionInspectionStyle lipgloss.Style
+	ActionStyle           lipgloss.Style
+	ReadmeStyle           lipgloss.Style
+
+	LegendStyle = lipgloss.NewStyle().Padding(1, 0, 0, 2)
+
+	TitleStyle   lipgloss.Style
+	CacheWarning lipgloss.Style
+	StatusStyle  lipgloss.Style
+)
+
+func init() {
+	Apply(Auto)
+}
+
+// Apply makes the theme the current one and derives all styles from it.
+// Lists and models created before keep their previous styles.
+func Apply(t Theme) {
+	current = t
+	t.setBackground()
+
+	Highlight = t.Highlight
 
 	ErrorStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(Highlight).Padding(0, 1)
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Highlight).Padding(0, 1).
+		Foreground(t.Error)
+
+	ErrorMessageStyle = lipgloss.NewStyle().
+		Foreground(t.Error).Bold(true)
 
 	TargetStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(Highlight)
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Highlight)
 
 	ActionInspectionStyle = lipgloss.NewStyle().
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderForeground(Highlight).Padding(0, 1).Faint(true)
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Highlight).Padding(0, 1).Faint(true)
 
 	ActionStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(Highlight)
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Highlight)
 
 	ReadmeStyle = lipgloss.NewStyle().
 		// BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(Highlight)
-
-	LegendStyle = lipgloss.NewStyle().Padding(1, 0, 0, 2)
+		BorderForeground(t.Highlight)
 
 	TitleStyle = lipgloss.NewStyle().
-			Foreground(Highlight).Bold(true).
-			Padding(1, 1)
+		Foreground(t.Highlight).Bold(true).
+		Padding(1, 1)
 
 	CacheWarning = lipgloss.NewStyle().
-			Foreground(Highlight).Bold(true).MarginLeft(4)
-)
+		Foreground(t.Highlight).Bold(true).MarginLeft(4)
+
+	StatusStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).MarginLeft(4)
+}
+
+// NewDelegate returns a list delegate with the item styles of the theme.
+func NewDelegate() list.DefaultDelegate {
+	t := current
+	d := list.NewDefaultDelegate()
+	s := &d.Styles
+	s.NormalTitle = s.NormalTitle.Copy().Foreground(t.Text)
+	s.NormalDesc = s.NormalDesc.Copy().Foreground(t.Muted)
+	s.SelectedTitle = s.SelectedTitle.Copy().Foreground(t.Selected).BorderForeground(t.Selected).Bold(t.NoColor)
+	s.SelectedDesc = s.SelectedDesc.Copy().Foreground(t.Selected).BorderForeground(t.Selected)
+	s.DimmedTitle = s.DimmedTitle.Copy().Foreground(t.Muted)
+	s.DimmedDesc = s.DimmedDesc.Copy().Foreground(t.Muted)
+	return d
+}
+
+// StyleList applies the theme to the list's title and status bar.
+func StyleList(l *list.Model) {
+	t := current
+	l.Styles.Title = l.Styles.Title.Copy().
+		Background(t.Highlight).
+		Foreground(t.TitleText).
+		Reverse(t.NoColor)
+	l.Styles.StatusBar = l.Styles.StatusBar.Copy().Foreground(t.Muted)
+	l.Styles.FilterPrompt = l.Styles.FilterPrompt.Copy().Foreground(t.Selected)
+	l.Styles.FilterCursor = l.Styles.FilterCursor.Copy().Foreground(t.Selected)
+}
+
+// NewHelp returns a help model in the colors of the theme.
+func NewHelp() help.Model {
+	t := current
+	h := help.New()
+	for _, s := range []*lipgloss.Style{&h.Styles.ShortKey, &h.Styles.FullKey} {
+		*s = s.Copy().Foreground(t.Text)
+	}
+	for _, s := range []*lipgloss.Style{
+		&h.Styles.ShortDesc, &h.Styles.FullDesc,
+		&h.Styles.ShortSeparator, &h.Styles.FullSeparator, &h.Styles.Ellipsis,
+	} {
+		*s = s.Copy().Foreground(t.Muted)
+	}
+	return h
+}
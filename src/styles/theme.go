@@ -0,0 +1,246 @@
+package styles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme holds the colors of the TUI.
+type Theme struct {
+	// Background forces the light or dark variant of adaptive colors,
+	// unless it is empty.
+	Background string
+	// NoColor renders without any colors, using text attributes instead.
+	NoColor bool
+
+	Highlight lipgloss.TerminalColor
+	TitleText lipgloss.TerminalColor
+	Text      lipgloss.TerminalColor
+	Muted     lipgloss.TerminalColor
+	Selected  lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+
+	// Markdown is a glamour style name, or the path of a glamour json
+	// style file. If empty, it follows the background.
+	Markdown string
+}
+
+const (
+	backgroundDark  = "dark"
+	backgroundLight = "light"
+)
+
+var (
+	// Auto adapts to the terminal's background.
+	Auto = Theme{
+		Highlight: lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"},
+		TitleText: lipgloss.Color("230"),
+		Text:      lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"},
+		Selected:  lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"},
+		Error:     lipgloss.AdaptiveColor{Light: "#D70000", Dark: "#FF5F87"},
+	}
+
+	Dark = Theme{
+		Background: backgroundDark,
+		Highlight:  lipgloss.Color("#7D56F4"),
+		TitleText:  lipgloss.Color("230"),
+		Text:       lipgloss.Color("#dddddd"),
+		Muted:      lipgloss.Color("#777777"),
+		Selected:   lipgloss.Color("#EE6FF8"),
+		Error:      lipgloss.Color("#FF5F87"),
+		Markdown:   "dark",
+	}
+
+	Light = Theme{
+		Background: backgroundLight,
+		Highlight:  lipgloss.Color("#874BFD"),
+		TitleText:  lipgloss.Color("230"),
+		Text:       lipgloss.Color("#1a1a1a"),
+		Muted:      lipgloss.Color("#A49FA5"),
+		Selected:   lipgloss.Color("#EE6FF8"),
+		Error:      lipgloss.Color("#D70000"),
+		Markdown:   "light",
+	}
+
+	// HighContrast uses the terminal's own bright and plain colors, and
+	// doesn't dim descriptions.
+	HighContrast = Theme{
+		Highlight: lipgloss.AdaptiveColor{Light: "4", Dark: "11"},
+		TitleText: lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+		Text:      lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+		Muted:     lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+		Selected:  lipgloss.AdaptiveColor{Light: "1", Dark: "14"},
+		Error:     lipgloss.AdaptiveColor{Light: "1", Dark: "9"},
+	}
+
+	Monochrome = Theme{
+		NoColor:   true,
+		Highlight: lipgloss.NoColor{},
+		TitleText: lipgloss.NoColor{},
+		Text:      lipgloss.NoColor{},
+		Muted:     lipgloss.NoColor{},
+		Selected:  lipgloss.NoColor{},
+		Error:     lipgloss.NoColor{},
+		Markdown:  "notty",
+	}
+
+	// Themes are the built-in themes by name.
+	Themes = map[string]Theme{
+		"auto":          Auto,
+		"dark":          Dark,
+		"light":         Light,
+		"high-contrast": HighContrast,
+		"monochrome":    Monochrome,
+	}
+)
+
+var current Theme
+
+// Current returns the applied theme.
+func Current() Theme { return current }
+
+// Load returns the built-in theme of that name or else reads the theme file
+// at that path. The 'auto' theme turns into 'monochrome' if $NO_COLOR is set.
+func Load(name string) (Theme, error) {
+	if name == "auto" && os.Getenv("NO_COLOR") != "" {
+		return Monochrome, nil
+	}
+	if t, ok := Themes[name]; ok {
+		return t, nil
+	}
+	if !strings.HasSuffix(name, ".toml") {
+		return Theme{}, fmt.Errorf("unknown theme '%s', must be a theme file or one of %v", name, ThemeNames())
+	}
+	return LoadFile(name)
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	return []string{"auto", "dark", "light", "high-contrast", "monochrome"}
+}
+
+// color is a color of a theme file: either a single color or a table
+// with a light and a dark variant.
+type color struct {
+	lipgloss.TerminalColor
+}
+
+func (c *color) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		c.TerminalColor = lipgloss.Color(v)
+	case map[string]interface{}:
+		light, _ := v["light"].(string)
+		dark, _ := v["dark"].(string)
+		if light == "" || dark == "" {
+			return errors.New("an adaptive color needs both a 'light' and a 'dark' color")
+		}
+		c.TerminalColor = lipgloss.AdaptiveColor{Light: light, Dark: dark}
+	default:
+		return fmt.Errorf("invalid color %v", v)
+	}
+	return nil
+}
+
+// themeFile is the format of a theme file: a built-in base theme and the
+// colors that differ from it.
+type themeFile struct {
+	Base       string `toml:"base"`
+	Background string `toml:"background"`
+	Highlight  *color `toml:"highlight"`
+	TitleText  *color `toml:"title-text"`
+	Text       *color `toml:"text"`
+	Muted      *color `toml:"muted"`
+	Selected   *color `toml:"selected"`
+	Error      *color `toml:"error"`
+	Markdown   string `toml:"markdown"`
+}
+
+// LoadFile reads a theme file.
+func LoadFile(path string) (Theme, error) {
+	var f themeFile
+	md, err := toml.DecodeFile(path, &f)
+	if err != nil {
+		return Theme{}, fmt.Errorf("while reading theme %s: %w", path, err)
+	}
+	for _, key := range md.Undecoded() {
+		// the keys of adaptive colors are decoded by the color itself
+		if len(key) == 1 {
+			return Theme{}, fmt.Errorf("while reading theme %s: unknown setting '%s'", path, key)
+		}
+	}
+	if f.Base == "" {
+		f.Base = "auto"
+	}
+	t, ok := Themes[f.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("while reading theme %s: unknown base theme '%s', must be one of %v", path, f.Base, ThemeNames())
+	}
+	switch f.Background {
+	case "", backgroundDark, backgroundLight:
+		if f.Background != "" {
+			t.Background = f.Background
+		}
+	default:
+		return Theme{}, fmt.Errorf("while reading theme %s: background must be '%s' or '%s'", path, backgroundDark, backgroundLight)
+	}
+	for _, c := range []struct {
+		from *color
+		to   *lipgloss.TerminalColor
+	}{
+		{f.Highlight, &t.Highlight},
+		{f.TitleText, &t.TitleText},
+		{f.Text, &t.Text},
+		{f.Muted, &t.Muted},
+		{f.Selected, &t.Selected},
+		{f.Error, &t.Error},
+	} {
+		if c.from != nil {
+			*c.to = c.from.TerminalColor
+		}
+	}
+	if f.Markdown != "" {
+		t.Markdown = f.Markdown
+	}
+	return t, nil
+}
+
+func (t Theme) setBackground() {
+	switch t.Background {
+	case backgroundDark:
+		lipgloss.SetHasDarkBackground(true)
+	case backgroundLight:
+		lipgloss.SetHasDarkBackground(false)
+	}
+	if t.NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// RenderMarkdown renders markdown in the style of the current theme.
+func RenderMarkdown(width int, content string) (string, error) {
+	style := glamour.WithStandardStyle(current.Markdown)
+	switch {
+	case current.Markdown == "":
+		background := backgroundLight
+		if lipgloss.HasDarkBackground() {
+			background = backgroundDark
+		}
+		style = glamour.WithStandardStyle(background)
+	case strings.HasSuffix(current.Markdown, ".json"):
+		style = glamour.WithStylesFromJSONFile(current.Markdown)
+	}
+	r, err := glamour.NewTermRenderer(glamour.WithWordWrap(width), style)
+	if err != nil {
+		return "", err
+	}
+	return r.Render(content)
+}
@@ -0,0 +1,61 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func writeTheme(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "theme.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	theme, err := Load(writeTheme(t, `
+base = "high-contrast"
+highlight = "#ff0000"
+error = { light = "1", dark = "9" }
+markdown = "dracula"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Highlight != lipgloss.Color("#ff0000") {
+		t.Errorf("highlight = %v", theme.Highlight)
+	}
+	if theme.Error != (lipgloss.AdaptiveColor{Light: "1", Dark: "9"}) {
+		t.Errorf("error = %v", theme.Error)
+	}
+	if theme.Text != HighContrast.Text || theme.Markdown != "dracula" {
+		t.Errorf("got %+v, want the rest from the base theme", theme)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	for _, content := range []string{
+		`base = "nope"`,
+		`highlight = { light = "1" }`,
+		`colour = "1"`,
+		`background = "grey"`,
+	} {
+		if _, err := Load(writeTheme(t, content)); err == nil {
+			t.Errorf("%s: got no error", content)
+		}
+	}
+}
+
+func TestLoadNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if theme, _ := Load("auto"); !theme.NoColor {
+		t.Error("auto theme with $NO_COLOR has colors")
+	}
+	if theme, _ := Load("dark"); theme.NoColor {
+		t.Error("explicit theme with $NO_COLOR has no colors")
+	}
+}
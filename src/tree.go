@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/paisano-nix/paisano/data"
+)
+
+// treeIndent indents the blocks of a cell and the targets of a block in the
+// tree view.
+const treeIndent = "  "
+
+// CellItem heads the blocks of a cell in the tree view.
+type CellItem struct {
+	r         *data.Root
+	CellIdx   int
+	Collapsed bool
+}
+
+func (i CellItem) Key() string { return "//" + i.r.Cells[i.CellIdx].Name }
+func (i CellItem) Title() string {
+	return fold(i.Collapsed) + i.Key()
+}
+func (i CellItem) Description() string {
+	c := i.r.Cells[i.CellIdx]
+	var targets int
+	for _, b := range c.Blocks {
+		targets += len(b.Targets)
+	}
+	return fmt.Sprintf("%s · %s", plural(len(c.Blocks), "block"), plural(targets, "target"))
+}
+func (i CellItem) FilterValue() string { return i.Key() }
+
+// BlockItem heads the targets of a block in the tree view.
+type BlockItem struct {
+	r         *data.Root
+	CellIdx   int
+	BlockIdx  int
+	Collapsed bool
+}
+
+func (i BlockItem) block() data.Block { return i.r.Cells[i.CellIdx].Blocks[i.BlockIdx] }
+func (i BlockItem) Key() string {
+	return fmt.Sprintf("//%s/%s", i.r.Cells[i.CellIdx].Name, i.block().Name)
+}
+func (i BlockItem) Title() string {
+	b := i.block()
+	return fmt.Sprintf("%s%s%s  [%s]", treeIndent, fold(i.Collapsed), b.Name, b.Blocktype)
+}
+func (i BlockItem) Description() string {
+	return treeIndent + plural(len(i.block().Targets), "target")
+}
+func (i BlockItem) FilterValue() string { return i.Key() }
+
+func fold(collapsed bool) string {
+	if collapsed {
+		return "▸ "
+	}
+	return "▾ "
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// nodeKey identifies a target, block or cell of the target list by its
+// path, e.g. '//backend/apps/api', '//backend/apps' or '//backend'.
+func nodeKey(item list.Item) string {
+	switch i := item.(type) {
+	case *TargetItem:
+		return i.Title()
+	case *BlockItem:
+		return i.Key()
+	case *CellItem:
+		return i.Key()
+	}
+	return ""
+}
+
+// ancestors returns the keys of the cell and the block of a node, as far
+// as it has them.
+func ancestors(key string) []string {
+	parts := strings.Split(strings.TrimPrefix(key, "//"), "/")
+	var keys []string
+	for i := 1; i < len(parts); i++ {
+		keys = append(keys, "//"+strings.Join(parts[:i], "/"))
+	}
+	return keys
+}
+
+// treeItems lists the cells, and the blocks and targets of those expanded.
+func (m *Tui) treeItems() []list.Item {
+	var items []list.Item
+	for ci, c := range m.r.Cells {
+		cell := &CellItem{m.r, ci, false}
+		cell.Collapsed = m.collapsed[cell.Key()]
+		items = append(items, cell)
+		if cell.Collapsed {
+			continue
+		}
+		for bi, b := range c.Blocks {
+			block := &BlockItem{m.r, ci, bi, false}
+			block.Collapsed = m.collapsed[block.Key()]
+			items = append(items, block)
+			if block.Collapsed {
+				continue
+			}
+			for ti := range b.Targets {
+				items = append(items, &TargetItem{m.r, ci, bi, ti, true})
+			}
+		}
+	}
+	return items
+}
+
+// listItems lists the targets, as a tree or flat.
+func (m *Tui) listItems() []list.Item {
+	if m.tree {
+		return m.treeItems()
+	}
+	return m.targetItems()
+}
+
+// relist lists the targets anew, e.g. after a node was expanded, keeping
+// the filter and moving the cursor to the node with the given key, if it's
+// listed.
+func (m *Tui) relist(key string) tea.Cmd {
+	items := m.listItems()
+	m.Left.Filter = targetFilter(items, m.tree)
+	if filter := m.Left.SetItems(items); filter != nil {
+		// filter right away, to find the node among the matches
+		m.Left, _ = m.Left.Update(filter())
+	}
+	for i, item := range m.Left.VisibleItems() {
+		if nodeKey(item) == key {
+			m.Left.Select(i)
+			break
+		}
+	}
+	return m.loadSelected()
+}
+
+// toggleTree switches between the tree and the flat list, keeping the
+// selected target, or the first target of the selected cell or block.
+func (m *Tui) toggleTree() tea.Cmd {
+	key := nodeKey(m.Left.SelectedItem())
+	m.tree = !m.tree
+	if m.tree {
+		m.reveal(key)
+	} else if m.selectedTarget() == nil {
+		for _, item := range m.targetItems() {
+			if t := item.(*TargetItem); strings.HasPrefix(t.Title(), key+"/") {
+				key = t.Title()
+				break
+			}
+		}
+	}
+	return m.relist(key)
+}
+
+// reveal expands the cell and block of a node, so that it's listed.
+func (m *Tui) reveal(key string) {
+	for _, k := range ancestors(key) {
+		delete(m.collapsed, k)
+	}
+}
+
+// toggleNode expands or collapses the selected cell or block.
+func (m *Tui) toggleNode() tea.Cmd {
+	key := nodeKey(m.Left.SelectedItem())
+	switch m.Left.SelectedItem().(type) {
+	case *CellItem, *BlockItem:
+		m.collapsed[key] = !m.collapsed[key]
+		return m.relist(key)
+	}
+	return nil
+}
+
+// expandAll expands all cells and blocks.
+func (m *Tui) expandAll() tea.Cmd {
+	for k := range m.collapsed {
+		delete(m.collapsed, k)
+	}
+	return m.relist(nodeKey(m.Left.SelectedItem()))
+}
+
+// collapseAll collapses all cells and blocks, moving the cursor to the cell
+// of the selected node.
+func (m *Tui) collapseAll() tea.Cmd {
+	key := nodeKey(m.Left.SelectedItem())
+	if a := ancestors(key); len(a) > 0 {
+		key = a[0]
+	}
+	for _, c := range m.r.Cells {
+		m.collapsed["//"+c.Name] = true
+		for _, b := range c.Blocks {
+			m.collapsed[fmt.Sprintf("//%s/%s", c.Name, b.Name)] = true
+		}
+	}
+	return m.relist(key)
+}
@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aymanbagabas/go-osc52"
 	"github.com/charmbracelet/bubbles/help"
@@ -13,12 +18,20 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kballard/go-shellquote"
 
+	"github.com/paisano-nix/paisano/cache"
+	"github.com/paisano-nix/paisano/config"
 	"github.com/paisano-nix/paisano/data"
+	"github.com/paisano-nix/paisano/filter"
 	"github.com/paisano-nix/paisano/flake"
+	"github.com/paisano-nix/paisano/graph"
+	"github.com/paisano-nix/paisano/history"
+	"github.com/paisano-nix/paisano/jobs"
 	"github.com/paisano-nix/paisano/keys"
 	"github.com/paisano-nix/paisano/models"
 	"github.com/paisano-nix/paisano/styles"
+	"github.com/paisano-nix/paisano/watch"
 )
 
 type Focus int64
@@ -29,14 +42,36 @@ const (
 	Right
 	Readme
 	Inspect
+	History
+	Deps
+	Systems
+	Args
+	Error
+	Profile
+	Output
+	Jobs
 
+	// FromFlake is metadata evaluated from the flake or a cache hit, which
+	// is keyed by the content of the flake's sources.
 	FromFlake Loaded = iota
-	FromCache
 	Loading
+	// Stale is metadata whose refresh failed.
+	Stale
+	// Reloading re-evaluates the flake on request or after its sources changed.
+	Reloading
 )
 
 var (
-	cmdTemplate = func(target, action string) string { return fmt.Sprintf("%[1]s %[2]s:%[3]s", argv0, target, action) }
+	cmdTemplate = func(system, target, action string, args []string) string {
+		cmd := fmt.Sprintf("%[1]s %[2]s:%[3]s", argv0, target, action)
+		if system != "" {
+			cmd = fmt.Sprintf("%[1]s --for %[4]s %[2]s:%[3]s", argv0, target, action, system)
+		}
+		if len(args) > 0 {
+			cmd += " " + shellquote.Join(args...)
+		}
+		return cmd
+	}
 )
 
 func (s Focus) String() string {
@@ -56,6 +91,8 @@ type TargetItem struct {
 	CellIdx   int
 	BlockIdx  int
 	TargetIdx int
+	// tree is set for targets listed in the tree view.
+	tree bool
 }
 
 func (i TargetItem) Title() string { return i.r.TargetTitle(i.CellIdx, i.BlockIdx, i.TargetIdx) }
@@ -64,6 +101,22 @@ func (i TargetItem) Description() string {
 }
 func (i TargetItem) FilterValue() string { return i.Title() }
 
+// highlights maps the positions of the target's title that matched a filter
+// to those of the title as it's rendered.
+func (i TargetItem) highlights(title []int) []int {
+	if !i.tree {
+		return title
+	}
+	offset := len(i.Title()) - len(i.r.TargetName(i.CellIdx, i.BlockIdx, i.TargetIdx))
+	var shifted []int
+	for _, j := range title {
+		if j >= offset {
+			shifted = append(shifted, j-offset+2*len(treeIndent))
+		}
+	}
+	return shifted
+}
+
 type Actions = list.Model
 
 type ActionItem struct {
@@ -84,47 +137,286 @@ func (i ActionItem) FilterValue() string { return i.Title() }
 
 type Tui struct {
 	r *data.Root
+	g *graph.Graph
 
 	Left          Targets
 	Right         Actions
 	Readme        *models.ReadmeModel
+	History       *models.HistoryModel
+	Deps          *models.DepsModel
+	Systems       *models.SystemsModel
+	Args          *models.ArgsModel
+	Error         *models.ErrorModel
+	Profile       *models.ProfileModel
+	Output        *models.OutputModel
+	Jobs          *models.JobsModel
 	Legend        help.Model
 	Keys          *keys.AppKeyMap
 	Title         string
+	System        string
+	ActionArgs    map[string][]string
 	InspectAction string
 	ExecveCommand *flake.RunActionCmd
-	Spinner       spinner.Model
-	FatalError    error
+	ExecveArgs    []string
+	// StayOpen runs actions in the output pane instead of quitting.
+	StayOpen bool
+	// JobManager runs the actions started in the TUI in the background.
+	JobManager *jobs.Manager
+	Spinner    spinner.Model
+	FatalError error
+	// Watcher, if set, reports changes to the flake's sources, upon which
+	// the flake is reloaded.
+	Watcher *watch.Watcher
 	Loaded
 	Focus
-	Width  int
-	Height int
+	lastFocus Focus
+	Width     int
+	Height    int
+	// waiting is set while waiting for the Watcher to report changes.
+	waiting bool
+	// loading is set while the flake is evaluated, and queued holds the
+	// progress of a refresh requested meanwhile.
+	loading  bool
+	queued   string
+	progress string
+	// status reports the outcome of the last refresh, until it times out.
+	status   string
+	statusID int
+	// followingJobs is set once the lifecycle events of the JobManager's
+	// jobs are received, which Init may not start again on a retry.
+	followingJobs bool
+	// marked holds the titles of the targets marked for a bulk run.
+	marked map[string]bool
+	// tree lists the targets under their cells and blocks, except for those
+	// collapsed, by the keys of their nodes (see nodeKey).
+	tree      bool
+	collapsed map[string]bool
 }
 
-func (m *Tui) LoadTargets() tea.Cmd {
+// targetDelegate renders marked targets with a marker after their title,
+// which keeps the highlights of filter matches in place, and the targets of
+// the tree view by their name, indented under their block.
+type targetDelegate struct {
+	list.DefaultDelegate
+	marked map[string]bool
+}
+
+func (d targetDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if t, ok := item.(*TargetItem); ok && (t.tree || d.marked[t.Title()]) {
+		item = targetView{t, d.marked[t.Title()]}
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+type targetView struct {
+	*TargetItem
+	marked bool
+}
+
+func (i targetView) Title() string {
+	title := i.TargetItem.Title()
+	if i.tree {
+		title = strings.Repeat(treeIndent, 2) + i.r.TargetName(i.CellIdx, i.BlockIdx, i.TargetIdx)
+	}
+	if i.marked {
+		title += " ◉"
+	}
+	return title
+}
+
+func (i targetView) Description() string {
+	if i.tree {
+		return strings.Repeat(treeIndent, 2) + i.TargetItem.Description()
+	}
+	return i.TargetItem.Description()
+}
+
+func (m *Tui) targetItems() []list.Item {
 	var (
 		numItems = m.r.Len()
 		counter  = 0
 	)
-	// Make list of actions
+	// Make list of targets
 	items := make([]list.Item, numItems)
 	for ci, c := range m.r.Cells {
 		for bi, b := range c.Blocks {
 			for ti := range b.Targets {
-				items[counter] = &TargetItem{m.r, ci, bi, ti}
+				items[counter] = &TargetItem{m.r, ci, bi, ti, false}
 				counter += 1
 			}
 		}
 	}
-	cmd := m.Left.SetItems(items)
-	if m.Left.SelectedItem() != nil {
-		target := m.Left.SelectedItem().(*TargetItem)
-		cmd = tea.Batch(cmd, m.LoadActions(target))
+	return items
+}
+
+func (m *Tui) LoadTargets() tea.Cmd {
+	items := m.listItems()
+	m.Left.Filter = targetFilter(items, m.tree)
+	return tea.Batch(m.Left.SetItems(items), m.loadSelected())
+}
+
+// selectedTarget returns the selected target, or nil if there is none or a
+// cell or block of the tree view is selected.
+func (m *Tui) selectedTarget() *TargetItem {
+	t, _ := m.Left.SelectedItem().(*TargetItem)
+	return t
+}
+
+// loadSelected lists the actions of the selected target, or the common
+// actions of the marked ones, if any.
+func (m *Tui) loadSelected() tea.Cmd {
+	if t := m.selectedTarget(); t != nil || len(m.marked) > 0 {
+		return m.LoadActions(t)
+	}
+	return m.Right.SetItems([]list.Item{})
+}
+
+// ReloadTargets replaces the targets with those of reloaded metadata,
+// keeping the filter as well as the selected target and action, if they
+// still exist.
+func (m *Tui) ReloadTargets() tea.Cmd {
+	var action string
+	target := nodeKey(m.Left.SelectedItem())
+	if a, ok := m.Right.SelectedItem().(*ActionItem); ok {
+		action = a.Title()
+	}
+	exists := map[string]bool{}
+	for _, item := range m.targetItems() {
+		exists[item.(*TargetItem).Title()] = true
+	}
+	for title := range m.marked {
+		if !exists[title] {
+			delete(m.marked, title)
+		}
+	}
+	items := m.listItems()
+	m.Left.Filter = targetFilter(items, m.tree)
+	if filter := m.Left.SetItems(items); filter != nil {
+		// filter right away, to find the selected target among the matches
+		m.Left, _ = m.Left.Update(filter())
+	}
+	visible := m.Left.VisibleItems()
+	if n := len(visible); n > 0 && m.Left.Index() >= n {
+		m.Left.Select(n - 1)
+	}
+	for i, item := range visible {
+		if nodeKey(item) == target {
+			m.Left.Select(i)
+			break
+		}
+	}
+	cmd := m.loadSelected()
+	for i, item := range m.Right.VisibleItems() {
+		if item.(*ActionItem).Title() == action {
+			m.Right.Select(i)
+			break
+		}
+	}
+	if m.Focus == Right || m.Focus == Inspect || m.Focus == Args {
+		if m.Right.SelectedItem() == nil {
+			// the action is gone
+			m.Args.Input.Blur()
+			m.Focus = Left
+			m.Title = ""
+			cmd = tea.Batch(cmd, m.Left.ToggleSpinner(), m.Right.ToggleSpinner())
+		} else {
+			m.SetTitle()
+			m.SetInspect()
+		}
+	}
+	return cmd
+}
+
+// targetFilter returns a list.FilterFunc for the given target items.
+// The term is a query like 'type:containers action:publish api', matched
+// fuzzily against the targets' titles, descriptions, block types and action
+// names, best matches first. Spec patterns (e.g. '//backend/*/api*') select
+// targets exactly like the CLI's 'list' does. The tree view keeps its order
+// and lists the cells and blocks of the matches.
+func targetFilter(items []list.Item, tree bool) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		f, err := filter.ParseQuery(term)
+		if err != nil {
+			return nil
+		}
+		var (
+			ranks  []list.Rank
+			scores []int
+			// the cell and block not yet listed for the next match
+			cell, block = -1, -1
+		)
+		for i, item := range items {
+			switch item.(type) {
+			case *CellItem:
+				cell = i
+				continue
+			case *BlockItem:
+				block = i
+				continue
+			}
+			t := item.(*TargetItem)
+			match, ok := f.Match(t.r.Select(t.CellIdx, t.BlockIdx, t.TargetIdx))
+			if !ok {
+				continue
+			}
+			for _, header := range []*int{&cell, &block} {
+				if *header >= 0 {
+					ranks = append(ranks, list.Rank{Index: *header})
+					*header = -1
+				}
+			}
+			ranks = append(ranks, list.Rank{Index: i, MatchedIndexes: t.highlights(match.TitleIndexes)})
+			scores = append(scores, match.Score)
+		}
+		if !tree {
+			sort.Stable(byScore{ranks, scores})
+		}
+		return ranks
+	}
+}
+
+// byScore sorts ranks by their scores, highest first.
+type byScore struct {
+	ranks  []list.Rank
+	scores []int
+}
+
+func (s byScore) Len() int           { return len(s.ranks) }
+func (s byScore) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+func (s byScore) Swap(i, j int) {
+	s.ranks[i], s.ranks[j] = s.ranks[j], s.ranks[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
+// SelectTarget moves the cursor of the target list to the target with the
+// given title, clearing the filter if it hides the target.
+func (m *Tui) SelectTarget(title string) tea.Cmd {
+	var cmd tea.Cmd
+	if m.tree {
+		m.reveal(title)
+		cmd = m.relist(title)
+	}
+	for i, item := range m.Left.VisibleItems() {
+		if t, ok := item.(*TargetItem); ok && t.Title() == title {
+			m.Left.Select(i)
+			return tea.Batch(cmd, m.LoadActions(t))
+		}
+	}
+	for i, item := range m.Left.Items() {
+		if t, ok := item.(*TargetItem); ok && t.Title() == title {
+			m.Left.ResetFilter()
+			m.Left.Select(i)
+			return tea.Batch(cmd, m.LoadActions(t))
+		}
 	}
 	return cmd
 }
 
 func (m *Tui) LoadActions(i *TargetItem) tea.Cmd {
+	if len(m.marked) > 0 {
+		return m.loadCommonActions()
+	}
+	m.Right.Title = "Actions"
 	_, _, t := m.r.Select(i.CellIdx, i.BlockIdx, i.TargetIdx)
 	var numItems = len(t.Actions)
 	// Make list of actions
@@ -135,28 +427,160 @@ func (m *Tui) LoadActions(i *TargetItem) tea.Cmd {
 	return m.Right.SetItems(items)
 }
 
+// Execute runs the action in the output pane if the TUI stays open, or
+// else quits the TUI to hand over to the action.
+func (m *Tui) Execute(cmd *flake.RunActionCmd, args []string) (tea.Model, tea.Cmd) {
+	if !m.StayOpen {
+		m.ExecveCommand = cmd
+		m.ExecveArgs = args
+		return m, tea.Quit
+	}
+	m.lastFocus = m.Focus
+	m.Focus = Output
+	return m, m.Output.Start([]flake.RunActionCmd{*cmd}, args)
+}
+
+// jobTitle renders the command line of a job.
+func jobTitle(j *jobs.Job) string {
+	cmd := argv0
+	if j.Cmd.System != "" {
+		cmd += " --for " + j.Cmd.System
+	}
+	cmd += " " + j.Cmd.Spec()
+	if len(j.Args) > 0 {
+		cmd += " " + shellquote.Join(j.Args...)
+	}
+	return cmd
+}
+
+// markedTargets returns the marked targets in the order of the flat list.
+func (m *Tui) markedTargets() []*TargetItem {
+	var targets []*TargetItem
+	for _, item := range m.targetItems() {
+		if t := item.(*TargetItem); m.marked[t.Title()] {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// loadCommonActions lists the actions all marked targets have, as those of
+// the first one.
+func (m *Tui) loadCommonActions() tea.Cmd {
+	targets := m.markedTargets()
+	m.Right.Title = fmt.Sprintf("Common actions of %d targets", len(targets))
+	if len(targets) == 1 {
+		m.Right.Title = "Actions of 1 marked target"
+	}
+	count := map[string]int{}
+	for _, t := range targets {
+		for _, a := range m.r.Target(t.CellIdx, t.BlockIdx, t.TargetIdx).Actions {
+			count[a.Name]++
+		}
+	}
+	var items []list.Item
+	first := targets[0]
+	for j, a := range m.r.Target(first.CellIdx, first.BlockIdx, first.TargetIdx).Actions {
+		if count[a.Name] == len(targets) {
+			items = append(items, &ActionItem{m.r, first.CellIdx, first.BlockIdx, first.TargetIdx, j})
+		}
+	}
+	return m.Right.SetItems(items)
+}
+
+// bulkCmds returns the commands running the action on all marked targets.
+func (m *Tui) bulkCmds(action string) []flake.RunActionCmd {
+	var cmds []flake.RunActionCmd
+	for _, t := range m.markedTargets() {
+		cmds = append(cmds, flake.RunActionCmd{
+			System: m.System,
+			Cell:   m.r.CellName(t.CellIdx, t.BlockIdx, t.TargetIdx),
+			Block:  m.r.BlockName(t.CellIdx, t.BlockIdx, t.TargetIdx),
+			Target: m.r.TargetName(t.CellIdx, t.BlockIdx, t.TargetIdx),
+			Action: action,
+		})
+	}
+	return cmds
+}
+
+// bulkTitle renders the command running the action on all marked targets.
+func (m *Tui) bulkTitle(action string) string {
+	cmd := argv0 + " run-many"
+	if m.System != "" {
+		cmd = fmt.Sprintf("%s run-many --for %s", argv0, m.System)
+	}
+	for _, c := range m.bulkCmds(action) {
+		cmd += " " + c.Spec()
+	}
+	return cmd
+}
+
+// toggleMark marks or unmarks the selected target and moves on to the next.
+func (m *Tui) toggleMark() tea.Cmd {
+	t := m.selectedTarget()
+	if m.marked[t.Title()] {
+		delete(m.marked, t.Title())
+	} else {
+		m.marked[t.Title()] = true
+	}
+	m.Left.CursorDown()
+	return m.loadSelected()
+}
+
 func (m *Tui) SetTitle() {
+	if i, ok := m.Right.SelectedItem().(*ActionItem); ok && len(m.marked) > 0 {
+		m.Title = m.bulkTitle(i.Title())
+		return
+	}
 
+	t := m.selectedTarget()
+	if t == nil {
+		m.Title = ""
+		return
+	}
 	if m.Right.SelectedItem() != nil {
 		m.Title = cmdTemplate(
-			m.Left.SelectedItem().(*TargetItem).Title(),
+			m.System,
+			t.Title(),
 			m.Right.SelectedItem().(*ActionItem).Title(),
+			m.ArgsFor(m.Right.SelectedItem().(*ActionItem)),
 		)
 	} else {
-		m.Title = lipgloss.NewStyle().Faint(true).Render(cmdTemplate(m.Left.SelectedItem().(*TargetItem).Title(), "n/a"))
+		m.Title = lipgloss.NewStyle().Faint(true).Render(cmdTemplate(m.System, t.Title(), "n/a", nil))
+	}
+}
+
+// ActionCmd returns the command running the action for the selected system.
+func (m *Tui) ActionCmd(i *ActionItem) *flake.RunActionCmd {
+	return &flake.RunActionCmd{
+		System: m.System,
+		Cell:   i.r.CellName(i.CellIdx, i.BlockIdx, i.TargetIdx),
+		Block:  i.r.BlockName(i.CellIdx, i.BlockIdx, i.TargetIdx),
+		Target: i.r.TargetName(i.CellIdx, i.BlockIdx, i.TargetIdx),
+		Action: i.r.ActionTitle(i.CellIdx, i.BlockIdx, i.TargetIdx, i.ActionIdx),
+	}
+}
+
+// ArgsFor returns the arguments the action is run with, those last entered
+// for it in this session.
+func (m *Tui) ArgsFor(i *ActionItem) []string {
+	return m.ActionArgs[m.ActionCmd(i).Spec()]
+}
+
+// promptArgs returns the arguments to prefill the prompt for the action
+// with: those last entered for it, or else those of its last run.
+func (m *Tui) promptArgs(i *ActionItem) []string {
+	spec := m.ActionCmd(i).Spec()
+	if args, ok := m.ActionArgs[spec]; ok {
+		return args
 	}
+	args, _ := history.LastArgs(spec)
+	return args
 }
 
 func (m *Tui) SetInspect() (tea.Model, tea.Cmd) {
 	if i, ok := m.Right.SelectedItem().(*ActionItem); ok {
-		cmd := flake.RunActionCmd{
-			System: "", // unlike in the CLI, we don't implement to specifiy system in the TUI
-			Cell:   i.r.CellName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-			Block:  i.r.BlockName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-			Target: i.r.TargetName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-			Action: i.r.ActionTitle(i.CellIdx, i.BlockIdx, i.TargetIdx, i.ActionIdx),
-		}
-		_, args, _ := cmd.Assemble(nil)
+		_, args, _ := m.ActionCmd(i).Assemble(nil)
 		m.InspectAction = "nix build " + strings.Join(args, " \\\n")
 		return m, nil
 	} else {
@@ -166,59 +590,140 @@ func (m *Tui) SetInspect() (tea.Model, tea.Cmd) {
 }
 
 type cellLoadedFromCacheMsg struct{ root *data.Root }
-type cellLoadedMsg struct{ root *data.Root }
-type cellLoadingFatalErrMsg struct{ err error }
+type cellLoadedMsg struct {
+	root *data.Root
+	took time.Duration
+}
+type cellLoadingErrMsg struct{ err error }
+type sourcesChangedMsg struct{ files []string }
+type watchErrMsg struct{ err error }
+type statusTimeoutMsg struct{ id int }
+
+// statusTimeout is how long the outcome of a refresh is shown.
+const statusTimeout = 5 * time.Second
+
+// reload evaluates the flake with non-blocking i/o and renews the cache.
+func reload(c *cache.Cache, key *cache.ActionID, cmd *exec.Cmd, buf *bytes.Buffer) tea.Cmd {
+	return func() tea.Msg {
+		stderr := new(bytes.Buffer)
+		cmd.Stderr = stderr
+		start := time.Now()
+		if err := cmd.Run(); err != nil {
+			return cellLoadingErrMsg{flake.AsEvalError(err, stderr.Bytes())}
+		}
+		took := time.Since(start)
+		bufA := &bytes.Buffer{}
+		r := io.TeeReader(buf, bufA)
+		root, err := LoadJson(r)
+		// renew cache under all circumstances (might have updated)
+		putMetadata(c, key, bufA.Bytes())
+		if err != nil {
+			return cellLoadingErrMsg{err}
+		}
+		return cellLoadedMsg{root, took}
+	}
+}
+
+// Refresh re-evaluates the flake in the background, showing the progress,
+// or once the current evaluation is done.
+func (m *Tui) Refresh(progress string) tea.Cmd {
+	if m.loading {
+		m.queued = progress
+		return nil
+	}
+	c, key, cmd, buf, err := flake.LoadFlakeCmd()
+	if err != nil {
+		return func() tea.Msg { return cellLoadingErrMsg{err} }
+	}
+	m.loading = true
+	if m.r != nil {
+		m.Loaded = Reloading
+	}
+	m.progress = progress
+	m.status = ""
+	return tea.Batch(reload(c, key, cmd, buf), m.Spinner.Tick)
+}
+
+// loaded ends the evaluation and starts a refresh requested meanwhile.
+func (m *Tui) loaded() tea.Cmd {
+	m.loading = false
+	if progress := m.queued; progress != "" {
+		m.queued = ""
+		return m.Refresh(progress)
+	}
+	return nil
+}
+
+// SetStatus shows the outcome of a refresh, or an error of the Watcher, for
+// a while.
+func (m *Tui) SetStatus(status string) tea.Cmd {
+	m.status = status
+	m.statusID++
+	id := m.statusID
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg { return statusTimeoutMsg{id} })
+}
+
+// WatchSources waits for the Watcher to report changes or an error, unless
+// it's waiting already or there is no Watcher.
+func (m *Tui) WatchSources() tea.Cmd {
+	if m.Watcher == nil || m.waiting {
+		return nil
+	}
+	m.waiting = true
+	return func() tea.Msg {
+		select {
+		case files, ok := <-m.Watcher.Changes:
+			if !ok {
+				return nil
+			}
+			return sourcesChangedMsg{files}
+		case err, ok := <-m.Watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return watchErrMsg{err}
+		}
+	}
+}
 
 func (m *Tui) Init() tea.Cmd {
 	var cmds []tea.Cmd
+	if !m.followingJobs {
+		m.followingJobs = true
+		cmds = append(cmds, m.JobManager.Wait())
+	}
 	c, key, cmd, buf, err := flake.LoadFlakeCmd()
 	if err != nil {
-		return func() tea.Msg { return cellLoadingFatalErrMsg{err} }
+		return tea.Batch(append(cmds, func() tea.Msg { return cellLoadingErrMsg{err} })...)
 	}
-	cached, _, err := c.GetBytes(*key)
+	m.loading = true
+	cached, err := getMetadata(c, key)
 	if err == nil {
-		// a cache hit ...
-		// ... load the cache
+		// on a cache hit, which is as good as the evaluation, load the cache
 		cmds = append(cmds, func() tea.Msg {
 			root, err := LoadJson(bytes.NewReader(cached))
 			if err != nil {
-				return cellLoadingFatalErrMsg{err}
+				return cellLoadingErrMsg{err}
 			}
 			return cellLoadedFromCacheMsg{root}
 		})
-		// ... re-load the flake with non-blocking i/o
-		cmds = append(cmds, func() tea.Msg {
-			stderr := new(bytes.Buffer)
-			cmd.Stderr = stderr
-			err = cmd.Run()
-			if err != nil {
-				return cellLoadingFatalErrMsg{fmt.Errorf(stderr.String())}
-			}
-			bufA := &bytes.Buffer{}
-			r := io.TeeReader(buf, bufA)
-			root, err := LoadJson(r)
-			// renew cache under all circumstances (might have updated)
-			c.PutBytes(*key, bufA.Bytes())
-			if err != nil {
-				return cellLoadingFatalErrMsg{err}
-			}
-			return cellLoadedMsg{root}
-		})
 	} else {
 		// on cache miss ...
-		// ... load the flake with blocking i/o
+		// ... load the flake with blocking i/o, showing nix' output
+		stderr := new(bytes.Buffer)
+		cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
 		cmds = append(cmds, tea.ExecProcess(cmd, func(err error) tea.Msg {
 			if err != nil {
-				return cellLoadingFatalErrMsg{err}
+				return cellLoadingErrMsg{flake.AsEvalError(err, stderr.Bytes())}
 			}
 			bufA := &bytes.Buffer{}
 			r := io.TeeReader(buf, bufA)
 			root, err := LoadJson(r)
-			c.PutBytes(*key, bufA.Bytes())
+			putMetadata(c, key, bufA.Bytes())
 			if err != nil {
-				return cellLoadingFatalErrMsg{err}
+				return cellLoadingErrMsg{err}
 			}
-			return cellLoadedMsg{root}
+			return cellLoadedMsg{root: root}
 		}))
 	}
 	cmds = append(cmds, m.Spinner.Tick)
@@ -233,29 +738,86 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	)
 	switch msg := msg.(type) {
 	case cellLoadedMsg:
+		if m.r != nil {
+			cmds = append(cmds, m.SetStatus(refreshStatus(m.r, msg.root, msg.took)))
+		}
 		m.r = msg.root
+		m.g = graph.New(msg.root)
 		m.Loaded = FromFlake
-		return m, tea.Batch(
-			m.LoadTargets(),
+		m.Profile.LastLoad, m.Profile.Cached = msg.took, false
+		if m.Focus == Error {
+			// the sources were fixed
+			m.Focus = Left
+		}
+		return m, tea.Batch(append(cmds,
+			m.ReloadTargets(),
 			m.Left.StartSpinner(),
-		)
+			m.WatchSources(),
+			m.loaded(),
+		)...)
 
 	case cellLoadedFromCacheMsg:
 		m.r = msg.root
-		m.Loaded = FromCache
+		m.g = graph.New(msg.root)
+		m.Loaded = FromFlake
+		m.Profile.LastLoad, m.Profile.Cached = 0, true
 		return m, tea.Batch(
 			m.LoadTargets(),
 			m.Left.StartSpinner(),
+			m.WatchSources(),
+			m.loaded(),
 		)
 
-	case cellLoadingFatalErrMsg:
-		m.FatalError = msg.err
-		return m, tea.Quit
+	case models.SystemsLoadedMsg:
+		m.Systems, cmd = m.Systems.Update(msg)
+		m.Systems.Select(m.System)
+		return m, cmd
+
+	case cellLoadingErrMsg:
+		m.Error.Show(msg.err)
+		m.Focus = Error
+		if m.Loaded == Reloading {
+			m.Loaded = Stale
+		}
+		return m, tea.Batch(m.WatchSources(), m.loaded())
+
+	case sourcesChangedMsg:
+		m.waiting = false
+		return m, m.Refresh(describeChanges(msg.files) + ", reloading")
+
+	case watchErrMsg:
+		m.waiting = false
+		return m, tea.Batch(m.WatchSources(), m.SetStatus("Watching failed: "+msg.err.Error()))
+
+	case jobs.StartedMsg, jobs.FinishedMsg:
+		m.Output, cmd = m.Output.Update(msg)
+		cmds = append(cmds, cmd)
+		m.Jobs, cmd = m.Jobs.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(append(cmds, m.JobManager.Wait())...)
+
+	case models.JobsTickMsg:
+		m.Jobs, cmd = m.Jobs.Update(msg)
+		return m, cmd
+
+	case models.ProfiledMsg:
+		m.Profile, cmd = m.Profile.Update(msg)
+		return m, cmd
+
+	case statusTimeoutMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+		return m, nil
 
 	case spinner.TickMsg:
+		m.Profile, cmd = m.Profile.Update(msg)
+		cmds = append(cmds, cmd)
+		m.Output, cmd = m.Output.Update(msg)
+		cmds = append(cmds, cmd)
 		if m.Loaded != Loading {
 			m.Spinner, cmd = m.Spinner.Update(msg)
-			return m, cmd
+			return m, tea.Batch(append(cmds, cmd)...)
 		}
 		m.Left, cmd = m.Left.Update(msg)
 		cmds = append(cmds, cmd)
@@ -267,11 +829,175 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if key.Matches(msg, m.Keys.ForceQuit) {
 			return m, tea.Quit
 		}
+		if m.Focus == Output {
+			switch {
+			case key.Matches(msg, m.Output.KeyMap.CloseOutput):
+				// the actions keep running in the background
+				m.Focus = m.lastFocus
+				if m.Focus == Right {
+					m.SetTitle()
+				} else {
+					m.Title = ""
+				}
+				return m, nil
+			case key.Matches(msg, m.Keys.Quit) && !m.Output.Running():
+				return m, tea.Quit
+			}
+			m.Output, cmd = m.Output.Update(msg)
+			return m, cmd
+		}
+		if m.Focus == Error {
+			switch {
+			case key.Matches(msg, m.Error.KeyMap.Retry):
+				m.Focus = Left
+				if m.r != nil {
+					return m, m.Refresh("Retrying")
+				}
+				m.Loaded = Loading
+				if m.loading {
+					return m, nil
+				}
+				return m, m.Init()
+			case key.Matches(msg, m.Error.KeyMap.Dismiss) && m.r != nil:
+				// keep using the cache
+				m.Focus = Left
+				m.Loaded = Stale
+				return m, nil
+			case key.Matches(msg, m.Error.KeyMap.Copy):
+				osc52.Copy(m.Error.Text())
+				return m, nil
+			case key.Matches(msg, m.Keys.Quit):
+				m.FatalError = m.Error.Err
+				return m, tea.Quit
+			}
+			m.Error, cmd = m.Error.Update(msg)
+			return m, cmd
+		}
+		if m.Focus == Args {
+			// the prompt's action, even if a reload changed the selection
+			c := m.Args.Cmd
+			switch {
+			case key.Matches(msg, m.Args.KeyMap.RunWithArgs):
+				args, err := m.Args.Args()
+				if err != nil {
+					return m, nil
+				}
+				m.ActionArgs[c.Spec()] = args
+				m.Args.Input.Blur()
+				m.Focus = Right
+				m.SetTitle()
+				return m.Execute(c, args)
+			case key.Matches(msg, m.Args.KeyMap.CloseArgs):
+				// keep valid arguments for the title, copy & execute
+				if args, err := m.Args.Args(); err == nil {
+					m.ActionArgs[c.Spec()] = args
+				}
+				m.Args.Input.Blur()
+				m.Focus = Right
+				m.SetTitle()
+				return m, nil
+			}
+			m.Args, cmd = m.Args.Update(msg)
+			return m, cmd
+		}
+		if m.Focus == Jobs {
+			switch {
+			case key.Matches(msg, m.Jobs.KeyMap.CloseJobs):
+				m.Focus = m.lastFocus
+				return m, nil
+			case key.Matches(msg, m.Jobs.KeyMap.CancelJob):
+				if j := m.Jobs.Selected(); j != nil {
+					j.Cancel()
+				}
+				return m, nil
+			case key.Matches(msg, m.Jobs.KeyMap.Rerun):
+				if j := m.Jobs.Selected(); j != nil {
+					m.Title = jobTitle(j)
+					m.Focus = Output
+					return m, m.Output.Start([]flake.RunActionCmd{j.Cmd}, j.Args)
+				}
+				return m, nil
+			case key.Matches(msg, m.Jobs.KeyMap.OpenLog):
+				if j := m.Jobs.Selected(); j != nil {
+					m.Title = jobTitle(j)
+					m.Focus = Output
+					return m, m.Output.Open(j)
+				}
+				return m, nil
+			case key.Matches(msg, m.Keys.Quit):
+				return m, tea.Quit
+			}
+			m.Jobs, cmd = m.Jobs.Update(msg)
+			return m, cmd
+		}
+		if m.Focus == Profile {
+			switch {
+			case key.Matches(msg, m.Profile.KeyMap.CloseProfile):
+				m.Focus = m.lastFocus
+				return m, nil
+			case key.Matches(msg, m.Profile.KeyMap.Rerun):
+				return m, m.Profile.Run()
+			case key.Matches(msg, m.Keys.Quit):
+				return m, tea.Quit
+			}
+			m.Profile, cmd = m.Profile.Update(msg)
+			return m, cmd
+		}
 		// Quit action inspection if enabled.
 		if m.Focus == Inspect && key.Matches(msg, actionKeys.QuitInspect) {
 			m.Focus = Right
 			return m, nil
 		}
+		if m.Focus == History {
+			switch {
+			case key.Matches(msg, m.History.KeyMap.CloseHistory):
+				m.Focus = Left
+				return m, nil
+			case key.Matches(msg, m.History.KeyMap.Rerun):
+				if e := m.History.Selected(); e != nil {
+					m.Focus = Left
+					m.Title = e.CommandLine(argv0)
+					return m.Execute(e.Cmd(), e.Args)
+				}
+				return m, nil
+			}
+		}
+		if m.Focus == Systems {
+			switch {
+			case key.Matches(msg, m.Systems.KeyMap.CloseSystems):
+				m.Focus = m.lastFocus
+				return m, nil
+			case key.Matches(msg, m.Systems.KeyMap.SelectSystem):
+				if s := m.Systems.Selected(); s != nil {
+					// the current system is nix' default, so it needs no '--for'
+					m.System = s.Name
+					if s.Current {
+						m.System = ""
+					}
+				}
+				m.Focus = m.lastFocus
+				// the actions and the command are for the system
+				cmd = m.loadSelected()
+				m.SetTitle()
+				if m.Focus == Right {
+					m.SetInspect()
+				}
+				return m, cmd
+			}
+		}
+		if m.Focus == Deps {
+			switch {
+			case key.Matches(msg, m.Deps.KeyMap.CloseDeps):
+				m.Focus = Left
+				return m, nil
+			case key.Matches(msg, m.Deps.KeyMap.JumpTo):
+				if d := m.Deps.Selected(); d != nil && d.Known {
+					m.Focus = Left
+					return m, m.SelectTarget(d.Id)
+				}
+				return m, nil
+			}
+		}
 		// Don't match any of the keys below if we're actively filtering.
 		if m.Left.FilterState() == list.Filtering {
 			break
@@ -279,27 +1005,90 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if key.Matches(msg, m.Keys.Quit) {
 			return m, tea.Quit
 		}
+		if (m.Focus == Left || m.Focus == Right) && key.Matches(msg, m.Keys.ShowSystems) {
+			m.lastFocus = m.Focus
+			m.Focus = Systems
+			m.Systems.Select(m.System)
+			return m, m.Systems.LoadSystems()
+		}
+		if (m.Focus == Left || m.Focus == Right) && key.Matches(msg, m.Keys.Refresh) {
+			return m, m.Refresh("Refreshing")
+		}
+		if (m.Focus == Left || m.Focus == Right) && key.Matches(msg, m.Keys.ShowProfile) {
+			m.lastFocus = m.Focus
+			m.Focus = Profile
+			if m.Profile.Profile == nil && m.Profile.Err == nil {
+				return m, m.Profile.Run()
+			}
+			return m, nil
+		}
+		if (m.Focus == Left || m.Focus == Right) && key.Matches(msg, m.Keys.ShowJobs) {
+			m.lastFocus = m.Focus
+			m.Focus = Jobs
+			return m, m.Jobs.Refresh()
+		}
+		if (m.Focus == Left || m.Focus == Right) && key.Matches(msg, m.Keys.ShowHistory) {
+			m.Focus = History
+			return m, m.History.LoadHistory()
+		}
 		// Don't match any of the keys below if no target is selected.
 		if m.Left.SelectedItem() == nil {
 			return m, nil
 		}
+		if m.Focus == Left && key.Matches(msg, m.Keys.ToggleTree) {
+			return m, m.toggleTree()
+		}
+		if m.Focus == Left && m.tree {
+			switch {
+			case key.Matches(msg, m.Left.KeyMap.Filter):
+				// the filter searches the collapsed targets, too
+				cmds = append(cmds, m.expandAll())
+			case key.Matches(msg, m.Keys.ToggleNode):
+				return m, m.toggleNode()
+			case key.Matches(msg, m.Keys.ExpandAll):
+				return m, m.expandAll()
+			case key.Matches(msg, m.Keys.CollapseAll):
+				return m, m.collapseAll()
+			}
+		}
+		// the keys below, except for browsing, are about the selected target
+		target := m.selectedTarget()
 		switch {
+		case m.Focus == Left && key.Matches(msg, m.Keys.Mark) && target != nil:
+			return m, m.toggleMark()
+		case m.Focus == Left && key.Matches(msg, m.Keys.ClearMarks):
+			for title := range m.marked {
+				delete(m.marked, title)
+			}
+			return m, m.loadSelected()
+		case m.Focus == Left && key.Matches(msg, m.Keys.ShowDeps) && target != nil:
+			m.Focus = Deps
+			return m, m.Deps.LoadDeps(m.g, target.Title())
+		case m.Focus == Right && key.Matches(msg, actionKeys.Exec) && len(m.marked) > 0:
+			if i, ok := m.Right.SelectedItem().(*ActionItem); ok {
+				m.lastFocus = m.Focus
+				m.Focus = Output
+				return m, m.Output.Start(m.bulkCmds(i.Title()), nil)
+			}
 		case m.Focus == Right && key.Matches(msg, actionKeys.Exec):
 			if i, ok := m.Right.SelectedItem().(*ActionItem); ok {
-				m.ExecveCommand = &flake.RunActionCmd{
-					System: "", // unlike in the CLI, we don't implement to specifiy system in the TUI
-					Cell:   i.r.CellName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-					Block:  i.r.BlockName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-					Target: i.r.TargetName(i.CellIdx, i.BlockIdx, i.TargetIdx),
-					Action: i.r.ActionTitle(i.CellIdx, i.BlockIdx, i.TargetIdx, i.ActionIdx),
-				}
-				return m, tea.Quit
+				return m.Execute(m.ActionCmd(i), m.ArgsFor(i))
+			}
+		case m.Focus == Right && key.Matches(msg, actionKeys.EditArgs) && len(m.marked) == 0:
+			if i, ok := m.Right.SelectedItem().(*ActionItem); ok {
+				m.Focus = Args
+				return m, m.Args.Prompt(m.ActionCmd(i), shellquote.Join(m.promptArgs(i)...))
 			}
 		case m.Focus == Right && key.Matches(msg, actionKeys.Copy):
-			if _, ok := m.Right.SelectedItem().(*ActionItem); ok {
+			if i, ok := m.Right.SelectedItem().(*ActionItem); ok && len(m.marked) > 0 {
+				osc52.Copy(m.bulkTitle(i.Title()))
+				return m, nil
+			} else if ok {
 				osc52.Copy(cmdTemplate(
-					m.Left.SelectedItem().(*TargetItem).Title(),
-					m.Right.SelectedItem().(*ActionItem).Title(),
+					m.System,
+					target.Title(),
+					i.Title(),
+					m.ArgsFor(i),
 				))
 				return m, nil
 			}
@@ -308,10 +1097,9 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		// toggle the help
 		case key.Matches(msg, m.Keys.ShowReadme):
-			if m.Focus == Left {
+			if m.Focus == Left && target != nil {
 				m.Focus = Readme
-				var t = m.Left.SelectedItem().(*TargetItem)
-				cmd = m.Readme.RenderMarkdown(m.r, t.CellIdx, t.BlockIdx, t.TargetIdx)
+				cmd = m.Readme.RenderMarkdown(m.r, target.CellIdx, target.BlockIdx, target.TargetIdx)
 				return m, cmd
 			}
 			if m.Focus == Right {
@@ -331,11 +1119,11 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// toggle the focus
 		case key.Matches(msg, m.Keys.ToggleFocus, m.Keys.FocusLeft, m.Keys.FocusRight):
 			// Don't toggle the focus if we're showing the help.
-			if m.Focus == Readme || m.Focus == Inspect {
+			if m.Focus == Readme || m.Focus == Inspect || m.Focus == History || m.Focus == Deps || m.Focus == Systems || m.Focus == Args || m.Focus == Jobs {
 				break
 			}
 			if m.Focus == Left {
-				if key.Matches(msg, m.Keys.FocusLeft) {
+				if key.Matches(msg, m.Keys.FocusLeft) || (target == nil && len(m.marked) == 0) {
 					return m, nil
 				}
 				m.Focus = Right
@@ -365,22 +1153,47 @@ func (m *Tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// size Readme
 		m.Readme.Height = msg.Height - 10
 		m.Readme.Width = msg.Width - 10
-		m.Readme, _ = m.Readme.Update(msg)
-		return m, nil
+		m.Readme, cmd = m.Readme.Update(msg)
+		// size History
+		m.History.List.SetSize(msg.Width-10, msg.Height-10)
+		// size Jobs
+		m.Jobs.List.SetSize(msg.Width-10, msg.Height-10)
+		// size Deps
+		m.Deps.List.SetSize(msg.Width-10, msg.Height-10)
+		// size Args
+		m.Args.Input.Width = msg.Width*2/3 - 16
+		// size Systems
+		m.Systems.List.SetSize(msg.Width-10, msg.Height-10)
+		// size Error
+		m.Error.SetSize(msg.Width-10, msg.Height-10)
+		// size Profile
+		m.Profile.SetSize(msg.Width-10, msg.Height-10)
+		// size Output
+		m.Output.SetSize(msg.Width-10, msg.Height-10)
+		return m, cmd
 	}
 	// route all other messages according to state
 	if m.Focus == Readme {
 		m.Readme, cmd = m.Readme.Update(msg)
 		cmds = append(cmds, cmd)
+	} else if m.Focus == History {
+		m.History, cmd = m.History.Update(msg)
+		cmds = append(cmds, cmd)
+	} else if m.Focus == Jobs {
+		m.Jobs, cmd = m.Jobs.Update(msg)
+		cmds = append(cmds, cmd)
+	} else if m.Focus == Deps {
+		m.Deps, cmd = m.Deps.Update(msg)
+		cmds = append(cmds, cmd)
+	} else if m.Focus == Systems {
+		m.Systems, cmd = m.Systems.Update(msg)
+		cmds = append(cmds, cmd)
+	} else if m.Focus == Args {
+		m.Args, cmd = m.Args.Update(msg)
+		cmds = append(cmds, cmd)
 	} else if m.Focus == Left {
 		m.Left, cmd = m.Left.Update(msg)
-		cmds = append(cmds, cmd)
-		if m.Left.SelectedItem() != nil {
-			var target = m.Left.SelectedItem().(*TargetItem)
-			cmds = append(cmds, m.LoadActions(target))
-		} else {
-			cmds = append(cmds, m.Right.SetItems([]list.Item{}))
-		}
+		cmds = append(cmds, cmd, m.loadSelected())
 	} else {
 		m.Right, cmd = m.Right.Update(msg)
 		m.SetTitle()
@@ -396,11 +1209,24 @@ func (m *Tui) View() string {
 	var cacheWarning string
 	if m.Loaded == Loading {
 		title = styles.TitleStyle.Render("Loading  " + m.Spinner.View())
-	} else if m.Loaded == FromCache {
-		title = styles.TitleStyle.Render(m.Title)
-		cacheWarning = styles.CacheWarning.Render("Using cache, refreshing: " + m.Spinner.View())
 	} else if m.Loaded == FromFlake {
 		title = styles.TitleStyle.Render(m.Title)
+		if m.status != "" {
+			cacheWarning = styles.StatusStyle.Render(m.status)
+		} else if n := m.JobManager.Running(); n == 1 {
+			cacheWarning = styles.StatusStyle.Render("1 job running")
+		} else if n > 1 {
+			cacheWarning = styles.StatusStyle.Render(fmt.Sprintf("%d jobs running", n))
+		}
+	} else if m.Loaded == Stale {
+		title = styles.TitleStyle.Render(m.Title)
+		cacheWarning = styles.CacheWarning.Render("Using cache, refreshing failed")
+	} else if m.Loaded == Reloading {
+		title = styles.TitleStyle.Render(m.Title)
+		cacheWarning = styles.CacheWarning.Render(m.progress + ": " + m.Spinner.View())
+	}
+	if m.Loaded != Loading && m.Title == "" && m.System != "" {
+		title = styles.TitleStyle.Render(lipgloss.NewStyle().Faint(true).Render("for " + m.System))
 	}
 
 	placementClosure := func(s string) string {
@@ -413,6 +1239,16 @@ func (m *Tui) View() string {
 		)
 	}
 
+	if m.Focus == Error {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				styles.TitleStyle.Render("Failed to load the flake"),
+				styles.TargetStyle.Render(m.Error.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
 	if m.Loaded == Loading {
 		return placementClosure(title)
 	}
@@ -427,6 +1263,87 @@ func (m *Tui) View() string {
 		)
 	}
 
+	if m.Focus == History {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				title,
+				styles.TargetStyle.Width(m.History.List.Width()).Height(m.History.List.Height()).Render(m.History.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Jobs {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				title,
+				styles.TargetStyle.Width(m.Jobs.List.Width()).Height(m.Jobs.List.Height()).Render(m.Jobs.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Output {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				styles.TitleStyle.Render(m.Title),
+				styles.TargetStyle.Render(m.Output.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Profile {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				styles.TitleStyle.Render("Evaluation profile"),
+				styles.TargetStyle.Render(m.Profile.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Systems {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				title,
+				styles.TargetStyle.Width(m.Systems.List.Width()).Height(m.Systems.List.Height()).Render(m.Systems.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Deps {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				title,
+				styles.TargetStyle.Width(m.Deps.List.Width()).Height(m.Deps.List.Height()).Render(m.Deps.View()),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
+	if m.Focus == Args {
+		return placementClosure(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				title,
+				lipgloss.JoinHorizontal(
+					lipgloss.Left,
+					styles.ActionInspectionStyle.Faint(false).Width(m.Left.Width()).Height(m.Left.Height()).Render(m.Args.View()),
+					styles.ActionStyle.Render(m.Right.View()),
+				),
+				styles.LegendStyle.Render(m.Legend.View(m)),
+			),
+		)
+	}
+
 	if m.Focus == Inspect {
 		return placementClosure(
 			lipgloss.JoinVertical(
@@ -465,11 +1382,51 @@ func (m *Tui) View() string {
 }
 
 func (m *Tui) ShortHelp() []key.Binding {
+	if m.Focus == Error {
+		return append(m.Error.ShortHelp(m.r != nil), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
 	if m.Focus == Readme {
 		return append(m.Readme.ShortHelp(), []key.Binding{
 			m.Keys.Quit,
 		}...)
 	}
+	if m.Focus == History {
+		return append(m.History.ShortHelp(), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
+	if m.Focus == Jobs {
+		return append(m.Jobs.ShortHelp(), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
+	if m.Focus == Args {
+		return m.Args.ShortHelp()
+	}
+	if m.Focus == Output {
+		kb := m.Output.ShortHelp()
+		if !m.Output.Running() {
+			kb = append(kb, m.Keys.Quit)
+		}
+		return kb
+	}
+	if m.Focus == Profile {
+		return append(m.Profile.ShortHelp(), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
+	if m.Focus == Systems {
+		return append(m.Systems.ShortHelp(), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
+	if m.Focus == Deps {
+		return append(m.Deps.ShortHelp(), []key.Binding{
+			m.Keys.Quit,
+		}...)
+	}
 	if m.Focus == Left {
 		// switch off the list's help
 		m.Left.KeyMap.ShowFullHelp.SetEnabled(false)
@@ -477,9 +1434,29 @@ func (m *Tui) ShortHelp() []key.Binding {
 		if m.Left.FilterState() == list.Filtering {
 			return m.Left.ShortHelp()
 		} else {
+			if m.tree && m.selectedTarget() == nil {
+				return append(m.Left.ShortHelp(), []key.Binding{
+					m.Keys.ToggleNode,
+					m.Keys.ExpandAll,
+					m.Keys.CollapseAll,
+					m.Keys.ToggleTree,
+					m.Keys.ShowSystems,
+					m.Keys.ShowHistory,
+					m.Keys.Refresh,
+					m.Keys.Quit,
+				}...)
+			}
 			return append(m.Left.ShortHelp(), []key.Binding{
 				m.Keys.ToggleFocus,
 				m.Keys.ShowReadme,
+				m.Keys.ShowDeps,
+				m.Keys.Mark,
+				m.Keys.ToggleTree,
+				m.Keys.ShowSystems,
+				m.Keys.ShowHistory,
+				m.Keys.Refresh,
+				m.Keys.ShowProfile,
+				m.Keys.ShowJobs,
 				m.Keys.Quit,
 			}...)
 		}
@@ -490,6 +1467,11 @@ func (m *Tui) ShortHelp() []key.Binding {
 		return append(m.Right.ShortHelp(), []key.Binding{
 			m.Keys.ToggleFocus,
 			m.Keys.ShowReadme,
+			m.Keys.ShowSystems,
+			m.Keys.ShowHistory,
+			m.Keys.Refresh,
+			m.Keys.ShowProfile,
+			m.Keys.ShowJobs,
 			m.Keys.Quit,
 		}...)
 	}
@@ -505,23 +1487,45 @@ func InitialPage() *Tui {
 	spin := spinner.New()
 	spin.Spinner = spinner.Points
 
+	// the delegate renders the marks, so they must not be replaced
+	marked := map[string]bool{}
+	targets := InitialTargets()
+	targets.SetDelegate(targetDelegate{styles.NewDelegate(), marked})
+
+	manager := jobs.NewManager()
+
 	return &Tui{
-		Left:    InitialTargets(),
+		Left:    targets,
 		Right:   NewActions(),
 		Keys:    keys.NewAppKeyMap(),
 		Focus:   Left,
 		Readme:  models.NewReadme(),
-		Legend:  help.New(),
+		History: models.NewHistory(),
+		Deps:    models.NewDeps(),
+		Systems: models.NewSystems(),
+		Args:    models.NewArgs(),
+		Error:   models.NewError(),
+		Profile: models.NewProfile(),
+		Output:  models.NewOutput(manager),
+		Jobs:    models.NewJobs(manager),
+		Legend:  styles.NewHelp(),
 		Loaded:  Loading,
 		Spinner: spin,
+
+		System:     config.Get().System,
+		ActionArgs: map[string][]string{},
+		JobManager: manager,
+		marked:     marked,
+		collapsed:  map[string]bool{},
 	}
 }
 
 func InitialTargets() Targets {
 
-	targetList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	targetList := list.New([]list.Item{}, styles.NewDelegate(), 0, 0)
+	styles.StyleList(&targetList)
 	targetList.Title = "Target"
-	targetList.KeyMap = keys.DefaultListKeyMap()
+	targetList.KeyMap = keys.TargetListKeyMap()
 	targetList.SetFilteringEnabled(true)
 	targetList.StartSpinner()
 	targetList.DisableQuitKeybindings()
@@ -533,11 +1537,11 @@ func InitialTargets() Targets {
 func NewActions() Actions {
 
 	newActionDelegate := func(keys *keys.ActionDelegateKeyMap) list.DefaultDelegate {
-		d := list.NewDefaultDelegate()
+		d := styles.NewDelegate()
 
 		d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 
-		help := []key.Binding{keys.Exec, keys.Copy}
+		help := []key.Binding{keys.Exec, keys.EditArgs, keys.Copy}
 		d.ShortHelpFunc = func() []key.Binding { return help }
 		d.FullHelpFunc = func() [][]key.Binding { return [][]key.Binding{} }
 
@@ -547,6 +1551,7 @@ func NewActions() Actions {
 	actionDelegateKeys := keys.NewActionDelegateKeyMap()
 	delegate := newActionDelegate(actionDelegateKeys)
 	actionList := list.New([]list.Item{}, delegate, 0, 0)
+	styles.StyleList(&actionList)
 	actionList.Title = "Actions"
 	actionList.KeyMap = keys.DefaultListKeyMap()
 	actionList.SetShowPagination(false)
@@ -557,3 +1562,41 @@ func NewActions() Actions {
 
 	return actionList
 }
+
+// describeChanges names the first changed file, relative to the working
+// directory, and counts the others.
+func describeChanges(files []string) string {
+	if len(files) == 0 {
+		return "Sources changed"
+	}
+	name := files[0]
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	if len(files) > 1 {
+		return fmt.Sprintf("%s and %d more changed", name, len(files)-1)
+	}
+	return name + " changed"
+}
+
+// refreshStatus reports how long the evaluation took and which targets it
+// added and removed.
+func refreshStatus(old, new *data.Root, took time.Duration) string {
+	status := "Refreshed"
+	if took > 0 {
+		status += " in " + took.Round(10*time.Millisecond).String()
+	}
+	added, removed := data.Diff(old, new)
+	switch {
+	case len(added) == 0 && len(removed) == 0:
+		return status + ", no targets added or removed"
+	case len(added)+len(removed) == 1:
+		if len(added) == 1 {
+			return status + ": added " + added[0]
+		}
+		return status + ": removed " + removed[0]
+	}
+	return fmt.Sprintf("%s: %d target(s) added, %d removed", status, len(added), len(removed))
+}
@@ -0,0 +1,169 @@
+// Package watch reports changes to the files the flake is evaluated from, so
+// that its metadata or an action can be refreshed as they're edited.
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a burst of changes must have settled before
+// it's reported, e.g. while an editor writes and renames a file.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Watcher watches directories recursively as well as single files and
+// reports their changes in batches.
+type Watcher struct {
+	// Changes receives the sorted paths changed during each burst of changes.
+	// Bursts happening while a batch isn't received yet are added to it.
+	Changes chan []string
+	// Errors receives errors of the underlying watcher. Errors are dropped
+	// while the last one isn't received yet.
+	Errors chan error
+
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+	trees    []string
+	files    map[string]bool
+}
+
+// New watches the paths, directories including their subdirectories.
+// Files are watched through their directory, so that they may be replaced,
+// and need not exist yet.
+func New(debounce time.Duration, paths ...string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Changes:  make(chan []string),
+		Errors:   make(chan error, 1),
+		fs:       fsw,
+		debounce: debounce,
+		files:    map[string]bool{},
+	}
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			w.trees = append(w.trees, p)
+			err = w.addTree(p)
+		} else {
+			w.files[p] = true
+			err = fsw.Add(filepath.Dir(p))
+		}
+		if err != nil {
+			fsw.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching and closes Changes and Errors.
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// e.g. removed while walking
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && ignored(path) {
+			return filepath.SkipDir
+		}
+		return w.fs.Add(path)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.Errors)
+	defer close(w.Changes)
+	var (
+		pending = map[string]bool{}
+		settled <-chan time.Time
+		batch   []string
+		out     chan []string // only set while a batch is due
+	)
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if !w.relevant(ev) {
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 && w.inTree(ev.Name) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					w.addTree(ev.Name)
+				}
+			}
+			pending[ev.Name] = true
+			settled = time.After(w.debounce)
+		case <-settled:
+			for _, p := range batch {
+				pending[p] = true
+			}
+			batch = batch[:0]
+			for p := range pending {
+				batch = append(batch, p)
+			}
+			sort.Strings(batch)
+			pending = map[string]bool{}
+			settled = nil
+			out = w.Changes
+		case out <- batch:
+			batch = nil
+			out = nil
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.Errors <- err:
+			default:
+			}
+		}
+	}
+}
+
+// relevant tells whether the event changes a watched file or a file in a
+// watched tree. Mere changes of permissions don't count.
+func (w *Watcher) relevant(ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	return w.files[ev.Name] || (w.inTree(ev.Name) && !ignored(ev.Name))
+}
+
+func (w *Watcher) inTree(path string) bool {
+	for _, t := range w.trees {
+		if strings.HasPrefix(path, t+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored tells whether the path is hidden, like '.git', or an editor's
+// backup or temporary file.
+func ignored(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "~") ||
+		strings.HasPrefix(name, "#") ||
+		// vim checks whether it may create files in a directory with it
+		name == "4913"
+}
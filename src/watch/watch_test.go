@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	cells := filepath.Join(dir, "nix")
+	if err := os.MkdirAll(filepath.Join(cells, "backend"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	flake := filepath.Join(dir, "flake.nix")
+	w, err := New(50*time.Millisecond, flake, filepath.Join(dir, "flake.lock"), cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	write := func(path string) {
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// neither watched files nor in the tree
+	write(filepath.Join(dir, "README.md"))
+	write(filepath.Join(cells, "backend", ".apps.nix.swp"))
+	// a burst of changes
+	write(flake)
+	write(filepath.Join(cells, "backend", "apps.nix"))
+	write(flake)
+	// in a directory created after the watcher
+	if err := os.Mkdir(filepath.Join(cells, "frontend"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	write(filepath.Join(cells, "frontend", "apps.nix"))
+
+	want := []string{
+		flake,
+		filepath.Join(cells, "backend", "apps.nix"),
+		filepath.Join(cells, "frontend"),
+		filepath.Join(cells, "frontend", "apps.nix"),
+	}
+	select {
+	case got := <-w.Changes:
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no changes reported")
+	}
+}